@@ -4,13 +4,20 @@ import (
 	"context"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/mauv0809/crispy-broccoli/internal/analysis"
 	"github.com/mauv0809/crispy-broccoli/internal/db"
 	"github.com/mauv0809/crispy-broccoli/internal/handlers"
 	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+	"github.com/mauv0809/crispy-broccoli/internal/jobs"
+	"github.com/mauv0809/crispy-broccoli/internal/pipeline"
+	"github.com/mauv0809/crispy-broccoli/internal/scheduler"
 
 	"github.com/mauv0809/crispy-broccoli/docs"
 )
@@ -70,20 +77,37 @@ func main() {
 	}))
 	e.Use(middleware.Recover())
 
-	// Setup handlers
-	h := handlers.New()
+	// Setup strategy registry and handlers
+	strategies := analysis.DefaultRegistry()
+	h := handlers.New(strategies)
 
 	// Setup repository and ingest client (if database is available)
 	var ingestHandler *handlers.IngestHandler
+	var analysisHandler *handlers.AnalysisHandler
+	var backtestHandler *handlers.BacktestHandler
+	var benchmarkHandler *handlers.BenchmarkHandler
+	var rebalanceHandler *handlers.RebalanceHandler
+	var portfolioHandler *handlers.PortfolioHandler
 	if pool != nil {
 		repo := db.NewRepository(pool)
-
-		// Setup ingest client (requires NASDAQ_API_KEY)
-		nasdaqAPIKey := os.Getenv("NASDAQ_API_KEY")
-		if nasdaqAPIKey != "" {
-			ingestClient := ingest.NewClient(nasdaqAPIKey)
-			ingestHandler = handlers.NewIngestHandler(ingestClient, repo)
-			log.Println("Ingest client initialized")
+		analysisHandler = handlers.NewAnalysisHandler(strategies, pool)
+		backtestHandler = handlers.NewBacktestHandler(strategies, pool)
+		benchmarkHandler = handlers.NewBenchmarkHandler(pool)
+		rebalanceHandler = handlers.NewRebalanceHandler(pool)
+		portfolioHandler = handlers.NewPortfolioHandler(pool)
+
+		// Setup ingest data source (requires NASDAQ_API_KEY or INGEST_REPLAY_DIR)
+		source := newDataSource(pool)
+		if source != nil {
+			deadLetters := db.NewDeadLetterRepository(pool)
+			runner := jobs.NewRunner(db.NewRunRepository(pool), deadLetters)
+			if err := runner.Recover(ctx); err != nil {
+				log.Printf("Warning: failed to recover ingest jobs: %v", err)
+			}
+			ingestPipeline := pipeline.New(source, repo)
+			ingestScheduler := scheduler.New(repo, ingestPipeline, runner, strategies.Dimensions())
+			go ingestScheduler.Start(ctx)
+			ingestHandler = handlers.NewIngestHandler(ingestPipeline, repo, deadLetters, runner, ingestScheduler)
 		} else {
 			log.Println("Warning: NASDAQ_API_KEY not set, ingestion endpoints disabled")
 		}
@@ -102,6 +126,32 @@ func main() {
 		return c.JSONBlob(200, []byte(docs.SwaggerInfo.ReadDoc()))
 	})
 
+	// Analysis routes (require database)
+	if analysisHandler != nil {
+		e.POST("/analyze", analysisHandler.Analyze)
+	}
+	if backtestHandler != nil {
+		e.GET("/backtest", backtestHandler.Page)
+		e.POST("/backtest", backtestHandler.Run)
+	}
+	if benchmarkHandler != nil {
+		e.GET("/api/portfolio/benchmark", benchmarkHandler.ComparePortfolio)
+	}
+	if rebalanceHandler != nil {
+		e.GET("/rebalance", rebalanceHandler.RebalanceTable)
+		e.GET("/api/rebalance", rebalanceHandler.Rebalance)
+	}
+	if portfolioHandler != nil {
+		e.GET("/portfolio", portfolioHandler.Page)
+		e.GET("/portfolio/table", portfolioHandler.Table)
+		e.GET("/portfolio/:id/edit", portfolioHandler.EditRow)
+		e.GET("/api/portfolio", portfolioHandler.List)
+		e.POST("/api/portfolio", portfolioHandler.Create)
+		e.GET("/api/portfolio/:id", portfolioHandler.Get)
+		e.PUT("/api/portfolio/:id", portfolioHandler.Update)
+		e.DELETE("/api/portfolio/:id", portfolioHandler.Delete)
+	}
+
 	// Admin routes for data ingestion
 	if ingestHandler != nil {
 		admin := e.Group("/admin")
@@ -109,7 +159,14 @@ func main() {
 		admin.POST("/ingest/tickers", ingestHandler.IngestTickers)
 		admin.POST("/ingest/fundamentals", ingestHandler.IngestFundamentals)
 		admin.POST("/ingest/daily", ingestHandler.IngestDaily)
+		admin.POST("/ingest/prices", ingestHandler.IngestPrices)
 		admin.POST("/ingest/benchmarks", ingestHandler.IngestBenchmarks)
+		admin.POST("/ingest/sp500", ingestHandler.IngestSP500)
+		admin.GET("/jobs/:id", ingestHandler.GetJob)
+		admin.DELETE("/jobs/:id", ingestHandler.CancelJob)
+		admin.GET("/runs", ingestHandler.RunsPage)
+		admin.GET("/dead-letters", ingestHandler.ListDeadLetters)
+		admin.POST("/dead-letters/retry", ingestHandler.RetryDeadLetters)
 		log.Println("Ingestion endpoints registered")
 	}
 
@@ -124,3 +181,65 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// newDataSource returns the source ingestion fetches from: recorded responses
+// when INGEST_REPLAY_DIR is set (offline), otherwise Nasdaq Data Link when
+// NASDAQ_API_KEY is set or a cassette is replayed, otherwise nil.
+func newDataSource(pool *pgxpool.Pool) ingest.DataSource {
+	if dir := os.Getenv("INGEST_REPLAY_DIR"); dir != "" {
+		log.Printf("Ingest replaying recorded responses from %s", dir)
+		return ingest.NewReplay(dir)
+	}
+
+	opts := []ingest.Option{
+		ingest.WithQuota(quotaFromEnv("NASDAQ_DAILY_CALL_QUOTA", ingest.DefaultCallQuota), quotaFromEnv("NASDAQ_DAILY_ROW_QUOTA", 0)),
+		ingest.WithUsageStore(db.NewUsageRepository(pool)),
+	}
+
+	// A cassette records every API page to a directory, or replays them offline
+	var mode ingest.CassetteMode
+	if dir := os.Getenv("INGEST_CASSETTE_DIR"); dir != "" {
+		var err error
+		mode, err = ingest.ParseCassetteMode(os.Getenv("INGEST_CASSETTE_MODE"))
+		if err != nil {
+			log.Fatalf("INGEST_CASSETTE_MODE: %v", err)
+		}
+		log.Printf("Ingest cassette: %s %s", mode, dir)
+		opts = append(opts, ingest.WithCassette(dir, mode))
+	}
+
+	// Strict schema refuses pages missing a required column instead of storing NULLs
+	if os.Getenv("INGEST_STRICT_SCHEMA") == "true" {
+		log.Println("Ingest schema checks are strict")
+		opts = append(opts, ingest.WithStrictSchema())
+	}
+
+	apiKey := os.Getenv("NASDAQ_API_KEY")
+	if apiKey == "" && mode != ingest.CassetteReplay {
+		return nil
+	}
+	if apiURL := os.Getenv("NASDAQ_API_URL"); apiURL != "" {
+		// Older .env files point at a table, e.g. .../datatables/SHARADAR/SF1
+		if i := strings.Index(apiURL, "/SHARADAR/"); i >= 0 {
+			apiURL = apiURL[:i]
+		}
+		opts = append(opts, ingest.WithBaseURL(apiURL))
+	}
+
+	log.Println("Ingest client initialized")
+	return ingest.NewClient(apiKey, opts...)
+}
+
+// quotaFromEnv reads a daily API quota, 0 meaning unlimited.
+func quotaFromEnv(name string, def int64) int64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		log.Printf("Warning: invalid %s %q, using %d", name, v, def)
+		return def
+	}
+	return n
+}
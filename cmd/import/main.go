@@ -0,0 +1,104 @@
+// Command import loads Sharadar tables from local CSV or zipped CSV files, as
+// downloaded from the Nasdaq website or a bulk export, without using the API.
+//
+// Usage:
+//
+//	go run ./cmd/import [-table TABLE] FILE...
+//
+// The table is taken from each file name (e.g. SHARADAR_SF1_2f9c1e.zip) unless
+// -table is given. Import TICKERS first: fundamentals and prices are only stored
+// for companies already in the database. Each file is recorded as an ingest run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+	"github.com/mauv0809/crispy-broccoli/internal/jobs"
+	"github.com/mauv0809/crispy-broccoli/internal/pipeline"
+)
+
+func main() {
+	table := flag.String("table", "", "Table of every file: "+strings.Join(ingest.FileTables, ", ")+" (default: from file name)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-table TABLE] FILE...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load .env file if it exists (local dev)
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		log.Fatal("DATABASE_URL environment variable is required")
+	}
+
+	// Ctrl-C cancels the current file; batches already upserted are kept
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := db.RunMigrations(databaseURL); err != nil {
+		log.Fatalf("Could not run migrations: %v", err)
+	}
+
+	pool, err := db.Connect(ctx, databaseURL)
+	if err != nil {
+		log.Fatalf("Could not connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	repo := db.NewRepository(pool)
+	runner := jobs.NewRunner(db.NewRunRepository(pool), db.NewDeadLetterRepository(pool))
+	p := pipeline.New(nil, repo) // Imports never call the API
+
+	failed := false
+	for _, path := range flag.Args() {
+		t := *table
+		if t == "" {
+			t = ingest.TableFromFilename(path)
+		}
+		if t == "" {
+			log.Printf("%s: cannot tell the table from the file name, use -table", path)
+			failed = true
+			continue
+		}
+
+		spec := jobs.Spec{
+			Kind:     strings.ToLower(t),
+			Endpoint: "import",
+			Params:   map[string]string{"file": path},
+		}
+		count, err := runner.Run(ctx, spec, func(ctx context.Context, progress *jobs.Progress) (int, error) {
+			return p.ImportFile(ctx, t, path, progress)
+		})
+		if err != nil {
+			log.Printf("%s: import failed: %v", path, err)
+			failed = true
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		log.Printf("%s: imported %d %s rows", path, count, t)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -15,9 +15,123 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/dead-letters": {
+            "get": {
+                "description": "Returns unresolved values nulled for overflowing their column and rows of failed database batches, with the row as fetched, the reason and the run ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingestion"
+                ],
+                "summary": "List dead-lettered rows",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only letters from this run",
+                        "name": "run_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only letters for this table (financial_metrics, daily_prices, equity_prices, fund_prices)",
+                        "name": "table",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum letters returned",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_models.DeadLetter"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/dead-letters/retry": {
+            "post": {
+                "description": "Starts a background job that upserts unresolved dead-lettered rows again, e.g. after a migration widens an overflowing column. Rows are never stored over data updated since they were fetched. Rows stored or skipped as stale are resolved; the rest record the new reason. Poll /admin/jobs/{id} for progress.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingestion"
+                ],
+                "summary": "Retry dead-lettered rows",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only letters from this run",
+                        "name": "run_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only letters for this table (financial_metrics, daily_prices, equity_prices, fund_prices)",
+                        "name": "table",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum letters retried (defaults to all)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/admin/ingest/benchmarks": {
             "post": {
-                "description": "Fetches daily data for configured benchmarks (e.g., SPY) from SHARADAR/DAILY",
+                "description": "Starts a background job that fetches fund prices for configured benchmarks (e.g., SPY) from SHARADAR/SFP. Poll /admin/jobs/{id} for progress.",
                 "consumes": [
                     "application/json"
                 ],
@@ -37,8 +151,8 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
                             "$ref": "#/definitions/internal_handlers.IngestResponse"
                         }
@@ -49,6 +163,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_handlers.IngestResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -60,7 +180,7 @@ const docTemplate = `{
         },
         "/admin/ingest/daily": {
             "post": {
-                "description": "Fetches daily price/fundamental data from SHARADAR/DAILY. If no ticker specified, fetches for all DB companies.",
+                "description": "Starts a background job that fetches daily price/fundamental data from SHARADAR/DAILY. If no ticker specified, fetches for all DB companies. Poll /admin/jobs/{id} for progress.",
                 "consumes": [
                     "application/json"
                 ],
@@ -86,8 +206,8 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
                             "$ref": "#/definitions/internal_handlers.IngestResponse"
                         }
@@ -98,6 +218,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_handlers.IngestResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -109,7 +235,7 @@ const docTemplate = `{
         },
         "/admin/ingest/fundamentals": {
             "post": {
-                "description": "Fetches fundamental data from SHARADAR/SF1 and upserts into financial_metrics table",
+                "description": "Starts a background job that fetches fundamental data from SHARADAR/SF1 and upserts into the financial_metrics table. Poll /admin/jobs/{id} for progress.",
                 "consumes": [
                     "application/json"
                 ],
@@ -142,8 +268,63 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/ingest/prices": {
+            "post": {
+                "description": "Starts a background job that fetches split-adjusted OHLC prices from SHARADAR/SEP, which backtests and portfolio valuation use. If no ticker specified, fetches for all DB companies. Poll /admin/jobs/{id} for progress.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingestion"
+                ],
+                "summary": "Ingest equity prices",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated tickers (defaults to all companies in DB)",
+                        "name": "ticker",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Fetch all history (default: incremental)",
+                        "name": "full",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
                             "$ref": "#/definitions/internal_handlers.IngestResponse"
                         }
@@ -154,6 +335,47 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_handlers.IngestResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/ingest/sp500": {
+            "post": {
+                "description": "Fetches the full added/removed history and current constituents from SHARADAR/SP500",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingestion"
+                ],
+                "summary": "Ingest S\u0026P 500 membership history",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -165,7 +387,7 @@ const docTemplate = `{
         },
         "/admin/ingest/status": {
             "get": {
-                "description": "Returns current data counts and last update timestamps",
+                "description": "Returns current data counts, last update timestamps, the ingestion schedule with its next run time, the most recent ingestion runs and today's API usage against the daily quota",
                 "produces": [
                     "application/json"
                 ],
@@ -212,6 +434,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_handlers.IngestResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -221,39 +449,828 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/admin/jobs/{id}": {
             "get": {
-                "description": "Returns the health status of the application",
+                "description": "Returns a background job's status, batches done, rows upserted (split into inserted, updated and unchanged when bulk-copied), errors and elapsed time",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "system"
+                    "ingestion"
+                ],
+                "summary": "Get ingestion job progress",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_models.IngestRun"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancels a running background job through its context. Batches already upserted are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingestion"
+                ],
+                "summary": "Cancel an ingestion job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.IngestResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "internal_handlers.IngestResponse": {
-            "type": "object",
-            "properties": {
-                "count": {
-                    "type": "integer"
-                },
-                "elapsed": {
-                    "type": "string"
+        },
+        "/analyze": {
+            "post": {
+                "description": "Runs the selected strategy and returns the proposed portfolio as an HTML partial",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "analysis"
+                ],
+                "summary": "Run a screening strategy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Strategy name",
+                        "name": "strategy",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "HTML partial",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/portfolio": {
+            "get": {
+                "description": "Returns all rows of the portfolio table",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "portfolio"
+                ],
+                "summary": "List holdings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_models.PortfolioHolding"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Adds a lot to the portfolio. The ticker must be a known company. Accepts JSON or form data; HTMX requests get the updated holdings table back.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "portfolio"
+                ],
+                "summary": "Add a holding",
+                "parameters": [
+                    {
+                        "description": "Holding",
+                        "name": "holding",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.HoldingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_models.PortfolioHolding"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/portfolio/benchmark": {
+            "get": {
+                "description": "Values the current holdings at daily closes over the window and compares total return, CAGR, max drawdown, Sharpe, alpha, beta, tracking error and information ratio against each benchmark",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "portfolio"
+                ],
+                "summary": "Compare the live portfolio to benchmarks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date (YYYY-MM-DD, default: one year ago)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (YYYY-MM-DD, default: today)",
+                        "name": "end",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_analysis.ComparisonReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/portfolio/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "portfolio"
+                ],
+                "summary": "Get a holding",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Holding ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_models.PortfolioHolding"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the shares, cost basis, target weight and acquired date of a holding. Accepts JSON or form data.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "portfolio"
+                ],
+                "summary": "Edit a holding",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Holding ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Holding",
+                        "name": "holding",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.HoldingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_models.PortfolioHolding"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "portfolio"
+                ],
+                "summary": "Remove a holding",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Holding ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rebalance": {
+            "get": {
+                "description": "Values holdings at the latest close and returns Buy/Add/Trim/Sell actions with dollar amounts and whole-share quantities",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "portfolio"
+                ],
+                "summary": "Calculate rebalancing trades",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_analysis.RebalancePlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/backtest": {
+            "post": {
+                "description": "Replays a strategy with quarterly rebalancing over stored prices and returns the results as an HTML partial",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "analysis"
+                ],
+                "summary": "Run a backtest",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Strategy name",
+                        "name": "strategy",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (YYYY-MM-DD)",
+                        "name": "start",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (YYYY-MM-DD)",
+                        "name": "end",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "default": 100000,
+                        "description": "Initial capital",
+                        "name": "capital",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "HTML partial",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Returns the health status of the application",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_mauv0809_crispy-broccoli_internal_analysis.BenchmarkComparison": {
+            "type": "object",
+            "properties": {
+                "alpha": {
+                    "type": "number"
+                },
+                "benchmark": {
+                    "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_analysis.Performance"
+                },
+                "beta": {
+                    "type": "number"
+                },
+                "days": {
+                    "description": "Overlapping trading days used",
+                    "type": "integer"
+                },
+                "delta": {
+                    "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_analysis.Performance"
+                },
+                "information_ratio": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "portfolio": {
+                    "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_analysis.Performance"
+                },
+                "ticker": {
+                    "type": "string"
+                },
+                "tracking_error": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_mauv0809_crispy-broccoli_internal_analysis.ComparisonReport": {
+            "type": "object",
+            "properties": {
+                "benchmarks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_analysis.BenchmarkComparison"
+                    }
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "performance": {
+                    "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_analysis.Performance"
+                },
+                "start_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mauv0809_crispy-broccoli_internal_analysis.Performance": {
+            "type": "object",
+            "properties": {
+                "cagr": {
+                    "type": "number"
+                },
+                "max_drawdown": {
+                    "description": "Negative, e.g. -18.4",
+                    "type": "number"
+                },
+                "sharpe_ratio": {
+                    "description": "Annualized, risk-free rate of 0",
+                    "type": "number"
+                },
+                "total_return": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_mauv0809_crispy-broccoli_internal_analysis.RebalanceAction": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "amount": {
+                    "description": "Dollars to buy (+) or sell (-)",
+                    "type": "number"
+                },
+                "current_value": {
+                    "type": "number"
+                },
+                "current_weight": {
+                    "description": "Fraction of total value (0-1)",
+                    "type": "number"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "description": "Whole shares to buy (+) or sell (-)",
+                    "type": "integer"
+                },
+                "shares": {
+                    "type": "number"
+                },
+                "target_weight": {
+                    "description": "Fraction of total value (0-1)",
+                    "type": "number"
+                },
+                "ticker": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mauv0809_crispy-broccoli_internal_analysis.RebalancePlan": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mauv0809_crispy-broccoli_internal_analysis.RebalanceAction"
+                    }
+                },
+                "total_value": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_mauv0809_crispy-broccoli_internal_models.DeadLetter": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "field": {
+                    "description": "Overflowing column, empty for failed batches",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_retry_at": {
+                    "type": "string"
+                },
+                "last_retry_error": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "retry_count": {
+                    "type": "integer"
+                },
+                "row": {
+                    "description": "The row as fetched",
+                    "type": "object"
+                },
+                "run_id": {
+                    "type": "integer"
+                },
+                "table": {
+                    "type": "string"
+                },
+                "ticker": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mauv0809_crispy-broccoli_internal_models.IngestRun": {
+            "type": "object",
+            "properties": {
+                "batches_done": {
+                    "type": "integer"
+                },
+                "elapsed": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "description": "API path, or \"scheduler\"",
+                    "type": "string"
+                },
+                "error_count": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "description": "First errors only",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "tickers, fundamentals, daily, prices, benchmarks, sp500, dead_letters",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "params": {
+                    "type": "object"
+                },
+                "rows_failed": {
+                    "description": "Rows in failed database batches",
+                    "type": "integer"
+                },
+                "rows_inserted": {
+                    "description": "New rows, counted by the COPY path only",
+                    "type": "integer"
+                },
+                "rows_unchanged": {
+                    "description": "Rows already stored as fetched, COPY path only",
+                    "type": "integer"
+                },
+                "rows_updated": {
+                    "description": "Rows with changed values, COPY path only",
+                    "type": "integer"
+                },
+                "rows_upserted": {
+                    "type": "integer"
+                },
+                "since": {
+                    "description": "Earliest incremental watermark, nil = all history",
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "values_overflowed": {
+                    "description": "Stored as NULL because they overflow their column",
+                    "type": "integer"
+                },
+                "warnings": {
+                    "description": "Schema drift, each recorded once",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "watermark_groups": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_mauv0809_crispy-broccoli_internal_models.PortfolioHolding": {
+            "type": "object",
+            "properties": {
+                "acquired_date": {
+                    "type": "string"
+                },
+                "cost_basis": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "shares_owned": {
+                    "type": "number"
+                },
+                "target_weight": {
+                    "type": "number"
+                },
+                "ticker": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_handlers.HoldingRequest": {
+            "type": "object",
+            "properties": {
+                "acquired_date": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "cost_basis": {
+                    "description": "Total cost of the lot",
+                    "type": "number"
+                },
+                "shares_owned": {
+                    "type": "number"
+                },
+                "target_weight": {
+                    "description": "Fraction of the portfolio (0-1)",
+                    "type": "number"
+                },
+                "ticker": {
+                    "type": "string",
+                    "example": "AAPL"
+                }
+            }
+        },
+        "internal_handlers.IngestResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "elapsed": {
+                    "type": "string"
+                },
+                "job_id": {
+                    "description": "Set for background jobs, poll /admin/jobs/{id}",
+                    "type": "integer"
                 },
                 "message": {
                     "type": "string"
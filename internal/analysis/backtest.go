@@ -0,0 +1,408 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultRebalanceMonths = 3                   // Quarterly rebalancing
+	maxBuyPriceAge         = 10 * 24 * time.Hour // Don't buy on prices staler than this, and sell holdings priced staler
+)
+
+// Trade is a single simulated buy or sell made during a backtest.
+type Trade struct {
+	Date   time.Time       `json:"date"`
+	Ticker string          `json:"ticker"`
+	Action string          `json:"action"` // "Buy" or "Sell"
+	Shares decimal.Decimal `json:"shares"`
+	Price  decimal.Decimal `json:"price"`
+	Amount decimal.Decimal `json:"amount"` // Shares × Price
+}
+
+// BacktestResult summarizes a strategy's simulated performance over a date range.
+type BacktestResult struct {
+	StrategyName   string          `json:"strategy_name"`
+	StartDate      time.Time       `json:"start_date"`
+	EndDate        time.Time       `json:"end_date"`
+	InitialCapital decimal.Decimal `json:"initial_capital"`
+	FinalValue     decimal.Decimal `json:"final_value"`
+	TotalReturn    float64         `json:"total_return"` // Percentage
+	CAGR           float64         `json:"cagr"`         // Compound Annual Growth Rate
+	MaxDrawdown    float64         `json:"max_drawdown"` // Worst peak-to-trough decline
+	SharpeRatio    float64         `json:"sharpe_ratio"` // Risk-adjusted return
+	Trades         []Trade         `json:"trades"`       // All rebalancing actions
+	Equity         []ValuePoint    `json:"equity"`       // Daily portfolio value
+}
+
+// Backtest replays a strategy over historical data.
+type Backtest interface {
+	Run(ctx context.Context, strategy Strategy, startDate, endDate time.Time) (*BacktestResult, error)
+}
+
+// Engine is a Backtest that rebalances into a strategy's picks at a fixed interval
+// and trades whole shares at the daily SEP close, which is split-adjusted.
+// Positions are valued from closeadj, which is also dividend-adjusted, so the
+// equity curve is a total return; dividends are paid into cash at each rebalance.
+type Engine struct {
+	pool            *pgxpool.Pool
+	InitialCapital  decimal.Decimal
+	RebalanceMonths int
+}
+
+// NewEngine creates a backtesting engine with quarterly rebalancing.
+func NewEngine(pool *pgxpool.Pool, initialCapital decimal.Decimal) *Engine {
+	return &Engine{
+		pool:            pool,
+		InitialCapital:  initialCapital,
+		RebalanceMonths: defaultRebalanceMonths,
+	}
+}
+
+// price is a close price and the day it was recorded.
+type price struct {
+	Close decimal.Decimal // Split-adjusted, what shares trade at
+	Adj   decimal.Decimal // Split- and dividend-adjusted (closeadj), zero when missing
+	Date  time.Time
+}
+
+// factor returns Adj / Close, or zero when closeadj is missing. It grows by the
+// dividend yield on each ex-dividend date, so its change between two days is
+// the dividends paid in between.
+func (p price) factor() decimal.Decimal {
+	if p.Adj.IsZero() || p.Close.IsZero() {
+		return decimal.Zero
+	}
+	return p.Adj.Div(p.Close)
+}
+
+// value returns shares valued at p, including the dividends paid since the day
+// the factor was base. Without both factors it is shares at the close.
+func (p price) value(shares, base decimal.Decimal) decimal.Decimal {
+	v := shares.Mul(p.Close)
+	if f := p.factor(); !f.IsZero() && !base.IsZero() {
+		v = v.Mul(f).Div(base)
+	}
+	return v
+}
+
+// portfolio is the simulated state carried between rebalances.
+type portfolio struct {
+	cash     decimal.Decimal
+	holdings map[string]decimal.Decimal // ticker -> shares
+	factors  map[string]decimal.Decimal // ticker -> price factor when dividends were last paid out
+}
+
+// Run implements Backtest.
+func (e *Engine) Run(ctx context.Context, strategy Strategy, startDate, endDate time.Time) (*BacktestResult, error) {
+	hs, ok := strategy.(HistoricalStrategy)
+	if !ok {
+		return nil, fmt.Errorf("strategy %q does not support backtesting", strategy.Name())
+	}
+	if !endDate.After(startDate) {
+		return nil, fmt.Errorf("end date must be after start date")
+	}
+
+	result := &BacktestResult{
+		StrategyName:   strategy.Name(),
+		StartDate:      startDate,
+		EndDate:        endDate,
+		InitialCapital: e.InitialCapital,
+		Equity:         []ValuePoint{{Date: startDate, Value: e.InitialCapital}},
+	}
+
+	pf := &portfolio{
+		cash:     e.InitialCapital,
+		holdings: make(map[string]decimal.Decimal),
+		factors:  make(map[string]decimal.Decimal),
+	}
+
+	dates := rebalanceDates(startDate, endDate, e.RebalanceMonths)
+	for i, date := range dates {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		recs, err := hs.RunScreenAsOf(ctx, e.pool, date)
+		if err != nil {
+			return nil, fmt.Errorf("running %s as of %s: %w", strategy.Name(), date.Format("2006-01-02"), err)
+		}
+
+		targets := make(map[string]decimal.Decimal, len(recs))
+		order := make([]string, 0, len(recs))
+		for _, rec := range recs {
+			targets[rec.Ticker] = rec.Weight
+			order = append(order, rec.Ticker)
+		}
+
+		trades, err := e.rebalance(ctx, date, pf, targets, order)
+		if err != nil {
+			return nil, err
+		}
+		result.Trades = append(result.Trades, trades...)
+		log.Printf("Backtest %s: rebalanced %d positions (%d trades)", date.Format("2006-01-02"), len(recs), len(trades))
+
+		// Value the portfolio daily until the next rebalance (or the end date, inclusive)
+		periodEnd := endDate.AddDate(0, 0, 1)
+		if i+1 < len(dates) {
+			periodEnd = dates[i+1]
+		}
+		points, err := e.valueSeries(ctx, pf, date, periodEnd)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range points {
+			if p.Date.After(result.Equity[len(result.Equity)-1].Date) {
+				result.Equity = append(result.Equity, p)
+			}
+		}
+	}
+
+	result.FinalValue = result.Equity[len(result.Equity)-1].Value
+	perf := computePerformance(result.Equity)
+	result.TotalReturn = perf.TotalReturn
+	result.CAGR = perf.CAGR
+	result.MaxDrawdown = perf.MaxDrawdown
+	result.SharpeRatio = perf.SharpeRatio
+
+	return result, nil
+}
+
+// rebalanceDates returns the rebalance dates from start (inclusive) to end (exclusive).
+func rebalanceDates(start, end time.Time, months int) []time.Time {
+	if months <= 0 {
+		months = defaultRebalanceMonths
+	}
+
+	var dates []time.Time
+	for d := start; d.Before(end); d = d.AddDate(0, months, 0) {
+		dates = append(dates, d)
+	}
+	return dates
+}
+
+// rebalance trades the portfolio toward the target weights at the close on date.
+// Positions are reduced first so the freed cash can fund the buys. Holdings whose
+// last close is older than maxBuyPriceAge have stopped trading, usually because
+// they were delisted, and are sold at that close on the day it was made.
+func (e *Engine) rebalance(ctx context.Context, date time.Time, pf *portfolio, targets map[string]decimal.Decimal, order []string) ([]Trade, error) {
+	tickers := make([]string, 0, len(pf.holdings)+len(order))
+	for ticker := range pf.holdings {
+		tickers = append(tickers, ticker)
+	}
+	for _, ticker := range order {
+		if _, held := pf.holdings[ticker]; !held {
+			tickers = append(tickers, ticker)
+		}
+	}
+
+	prices, err := e.closesAsOf(ctx, tickers, date)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pay the dividends accrued since the last rebalance into cash
+	for ticker, shares := range pf.holdings {
+		p, ok := prices[ticker]
+		if !ok {
+			continue
+		}
+		pf.cash = pf.cash.Add(p.value(shares, pf.factors[ticker]).Sub(shares.Mul(p.Close)))
+		if f := p.factor(); !f.IsZero() {
+			pf.factors[ticker] = f
+		}
+	}
+
+	// Value the portfolio before trading, each holding at its last close however old
+	total := pf.cash
+	for ticker, shares := range pf.holdings {
+		p, ok := prices[ticker]
+		if !ok {
+			log.Printf("Backtest %s: no price for %s, valuing position at zero", date.Format("2006-01-02"), ticker)
+			continue
+		}
+		if date.Sub(p.Date) > maxBuyPriceAge {
+			log.Printf("Backtest %s: last close for %s is from %s, selling at it", date.Format("2006-01-02"), ticker, p.Date.Format("2006-01-02"))
+		}
+		total = total.Add(shares.Mul(p.Close))
+	}
+
+	// Target share counts for picks with a recent price
+	targetShares := make(map[string]decimal.Decimal, len(targets))
+	for ticker, weight := range targets {
+		p, ok := prices[ticker]
+		if !ok || p.Close.IsZero() || date.Sub(p.Date) > maxBuyPriceAge {
+			continue
+		}
+		targetShares[ticker] = total.Mul(weight).Div(p.Close).Floor()
+	}
+
+	var trades []Trade
+
+	// Sells and trims
+	for ticker, shares := range pf.holdings {
+		p, ok := prices[ticker]
+		if !ok {
+			continue // Can't be sold without a price
+		}
+
+		// Stale holdings have no target, so they are sold whether picked or not
+		target := targetShares[ticker]
+		if !target.LessThan(shares) {
+			continue
+		}
+		tradeDate := date
+		if date.Sub(p.Date) > maxBuyPriceAge {
+			tradeDate = p.Date
+		}
+
+		sell := shares.Sub(target)
+		amount := sell.Mul(p.Close)
+		pf.cash = pf.cash.Add(amount)
+		if target.IsZero() {
+			delete(pf.holdings, ticker)
+			delete(pf.factors, ticker)
+		} else {
+			pf.holdings[ticker] = target
+		}
+		trades = append(trades, Trade{Date: tradeDate, Ticker: ticker, Action: "Sell", Shares: sell, Price: p.Close, Amount: amount})
+	}
+
+	// Buys and adds, in rank order
+	for _, ticker := range order {
+		target, ok := targetShares[ticker]
+		if !ok {
+			continue
+		}
+
+		held := pf.holdings[ticker]
+		if !target.GreaterThan(held) {
+			continue
+		}
+
+		p := prices[ticker]
+		buy := target.Sub(held)
+		if cost := buy.Mul(p.Close); cost.GreaterThan(pf.cash) {
+			buy = pf.cash.Div(p.Close).Floor()
+		}
+		if !buy.IsPositive() {
+			continue
+		}
+
+		amount := buy.Mul(p.Close)
+		pf.cash = pf.cash.Sub(amount)
+		pf.holdings[ticker] = held.Add(buy)
+		if held.IsZero() {
+			pf.factors[ticker] = p.factor()
+		}
+		trades = append(trades, Trade{Date: date, Ticker: ticker, Action: "Buy", Shares: buy, Price: p.Close, Amount: amount})
+	}
+
+	return trades, nil
+}
+
+// closesAsOf returns the most recent close on or before date for each ticker.
+func (e *Engine) closesAsOf(ctx context.Context, tickers []string, date time.Time) (map[string]price, error) {
+	prices := make(map[string]price, len(tickers))
+	if len(tickers) == 0 {
+		return prices, nil
+	}
+
+	rows, err := e.pool.Query(ctx, `
+		SELECT DISTINCT ON (ticker) ticker, date, close, COALESCE(close_adj, 0)
+		FROM equity_prices
+		WHERE ticker = ANY($1) AND date <= $2 AND close IS NOT NULL
+		ORDER BY ticker, date DESC
+	`, tickers, date)
+	if err != nil {
+		return nil, fmt.Errorf("querying closes as of %s: %w", date.Format("2006-01-02"), err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ticker string
+		var p price
+		if err := rows.Scan(&ticker, &p.Date, &p.Close, &p.Adj); err != nil {
+			return nil, fmt.Errorf("scanning close: %w", err)
+		}
+		prices[ticker] = p
+	}
+
+	return prices, rows.Err()
+}
+
+// valueSeries returns the daily value of the portfolio for trading days in [from, to),
+// with dividends since the last payout reinvested in the position. Missing closes
+// carry the last known price forward.
+func (e *Engine) valueSeries(ctx context.Context, pf *portfolio, from, to time.Time) ([]ValuePoint, error) {
+	if len(pf.holdings) == 0 {
+		return []ValuePoint{{Date: from, Value: pf.cash}}, nil
+	}
+
+	tickers := make([]string, 0, len(pf.holdings))
+	for ticker := range pf.holdings {
+		tickers = append(tickers, ticker)
+	}
+
+	last, err := e.closesAsOf(ctx, tickers, from)
+	if err != nil {
+		return nil, err
+	}
+
+	// Holdings that have never paid out, such as a live portfolio, accrue from the start
+	base := make(map[string]decimal.Decimal, len(tickers))
+	for _, ticker := range tickers {
+		base[ticker] = pf.factors[ticker]
+		if base[ticker].IsZero() {
+			base[ticker] = last[ticker].factor()
+		}
+	}
+
+	rows, err := e.pool.Query(ctx, `
+		SELECT ticker, date, close, COALESCE(close_adj, 0)
+		FROM equity_prices
+		WHERE ticker = ANY($1) AND date >= $2 AND date < $3 AND close IS NOT NULL
+		ORDER BY date, ticker
+	`, tickers, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("querying closes: %w", err)
+	}
+	defer rows.Close()
+
+	value := func() decimal.Decimal {
+		total := pf.cash
+		for ticker, shares := range pf.holdings {
+			total = total.Add(last[ticker].value(shares, base[ticker]))
+		}
+		return total
+	}
+
+	var points []ValuePoint
+	var current time.Time
+	for rows.Next() {
+		var ticker string
+		var p price
+		if err := rows.Scan(&ticker, &p.Date, &p.Close, &p.Adj); err != nil {
+			return nil, fmt.Errorf("scanning close: %w", err)
+		}
+
+		if !current.IsZero() && !p.Date.Equal(current) {
+			points = append(points, ValuePoint{Date: current, Value: value()})
+		}
+		current = p.Date
+		last[ticker] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !current.IsZero() {
+		points = append(points, ValuePoint{Date: current, Value: value()})
+	}
+
+	return points, nil
+}
@@ -0,0 +1,176 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/shopspring/decimal"
+)
+
+// BenchmarkComparison compares a portfolio against one benchmark over the dates both
+// have prices for. Delta is portfolio minus benchmark; alpha and tracking error are
+// annualized percentages.
+type BenchmarkComparison struct {
+	Ticker           string      `json:"ticker"`
+	Name             string      `json:"name"`
+	Portfolio        Performance `json:"portfolio"`
+	Benchmark        Performance `json:"benchmark"`
+	Delta            Performance `json:"delta"`
+	Alpha            float64     `json:"alpha"`
+	Beta             float64     `json:"beta"`
+	TrackingError    float64     `json:"tracking_error"`
+	InformationRatio float64     `json:"information_ratio"`
+	Days             int         `json:"days"` // Overlapping trading days used
+}
+
+// ComparisonReport is the benchmark comparison table for a backtest or live portfolio.
+type ComparisonReport struct {
+	Name        string                `json:"name"`
+	StartDate   time.Time             `json:"start_date"`
+	EndDate     time.Time             `json:"end_date"`
+	Performance Performance           `json:"performance"`
+	Benchmarks  []BenchmarkComparison `json:"benchmarks"`
+}
+
+// CompareBenchmarks compares a value series against every configured benchmark
+// over the same window.
+func CompareBenchmarks(ctx context.Context, pool *pgxpool.Pool, name string, series []ValuePoint) (*ComparisonReport, error) {
+	if len(series) < 2 {
+		return nil, fmt.Errorf("need at least two values to compare")
+	}
+
+	report := &ComparisonReport{
+		Name:        name,
+		StartDate:   series[0].Date,
+		EndDate:     series[len(series)-1].Date,
+		Performance: computePerformance(series),
+	}
+
+	repo := db.NewRepository(pool)
+	benchmarks, err := repo.GetBenchmarks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range benchmarks {
+		prices, err := repo.GetBenchmarkPrices(ctx, b.Ticker, report.StartDate, report.EndDate)
+		if err != nil {
+			return nil, err
+		}
+
+		// Adjusted closes, so the benchmark's return includes its distributions
+		bench := make([]ValuePoint, len(prices))
+		for i, p := range prices {
+			bench[i] = ValuePoint{Date: p.Date, Value: p.CloseAdj}
+		}
+
+		cmp := compareSeries(series, bench)
+		cmp.Ticker = b.Ticker
+		cmp.Name = b.Name
+		report.Benchmarks = append(report.Benchmarks, cmp)
+	}
+
+	return report, nil
+}
+
+// compareSeries computes the comparison on the dates present in both series.
+func compareSeries(portfolio, benchmark []ValuePoint) BenchmarkComparison {
+	p, b := alignSeries(portfolio, benchmark)
+
+	cmp := BenchmarkComparison{
+		Portfolio: computePerformance(p),
+		Benchmark: computePerformance(b),
+		Days:      len(p),
+	}
+	cmp.Delta = Performance{
+		TotalReturn: cmp.Portfolio.TotalReturn - cmp.Benchmark.TotalReturn,
+		CAGR:        cmp.Portfolio.CAGR - cmp.Benchmark.CAGR,
+		MaxDrawdown: cmp.Portfolio.MaxDrawdown - cmp.Benchmark.MaxDrawdown,
+		SharpeRatio: cmp.Portfolio.SharpeRatio - cmp.Benchmark.SharpeRatio,
+	}
+
+	rp := dailyReturns(p)
+	rb := dailyReturns(b)
+	if len(rp) != len(rb) || len(rp) < 2 {
+		return cmp
+	}
+
+	meanP, _ := meanStd(rp)
+	meanB, stdB := meanStd(rb)
+	if stdB > 0 {
+		cmp.Beta = covariance(rp, rb, meanP, meanB) / (stdB * stdB)
+	}
+	cmp.Alpha = (meanP - cmp.Beta*meanB) * tradingDaysPerYear * 100
+
+	active := make([]float64, len(rp))
+	for i := range rp {
+		active[i] = rp[i] - rb[i]
+	}
+	meanActive, stdActive := meanStd(active)
+	cmp.TrackingError = stdActive * math.Sqrt(tradingDaysPerYear) * 100
+	if stdActive > 0 {
+		cmp.InformationRatio = meanActive / stdActive * math.Sqrt(tradingDaysPerYear)
+	}
+
+	return cmp
+}
+
+// alignSeries returns the points of a and b on the dates they have in common.
+func alignSeries(a, b []ValuePoint) ([]ValuePoint, []ValuePoint) {
+	byDate := make(map[time.Time]decimal.Decimal, len(b))
+	for _, p := range b {
+		byDate[p.Date.Truncate(24*time.Hour)] = p.Value
+	}
+
+	var outA, outB []ValuePoint
+	for _, p := range a {
+		day := p.Date.Truncate(24 * time.Hour)
+		if v, ok := byDate[day]; ok {
+			outA = append(outA, p)
+			outB = append(outB, ValuePoint{Date: p.Date, Value: v})
+		}
+	}
+	return outA, outB
+}
+
+// covariance returns the sample covariance of xs and ys given their means.
+func covariance(xs, ys []float64, meanX, meanY float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	sum := 0.0
+	for i := range xs {
+		sum += (xs[i] - meanX) * (ys[i] - meanY)
+	}
+	return sum / float64(len(xs)-1)
+}
+
+// ErrNoHoldings is returned by HoldingsValueSeries when no holding has shares.
+var ErrNoHoldings = errors.New("portfolio has no holdings")
+
+// HoldingsValueSeries values the current portfolio holdings at each daily close
+// between start and end (inclusive), as if they had been held the whole time.
+func HoldingsValueSeries(ctx context.Context, pool *pgxpool.Pool, start, end time.Time) ([]ValuePoint, error) {
+	holdings, err := db.NewPortfolioRepository(pool).List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pf := &portfolio{holdings: make(map[string]decimal.Decimal, len(holdings))}
+	for _, h := range holdings {
+		if h.SharesOwned.IsPositive() {
+			pf.holdings[h.Ticker] = pf.holdings[h.Ticker].Add(h.SharesOwned)
+		}
+	}
+	if len(pf.holdings) == 0 {
+		return nil, ErrNoHoldings
+	}
+
+	engine := &Engine{pool: pool}
+	return engine.valueSeries(ctx, pf, start, end.AddDate(0, 0, 1))
+}
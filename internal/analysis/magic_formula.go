@@ -0,0 +1,245 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultPortfolioSize = 6 // Used when settings.portfolio_size is missing
+	maxPicksPerSector    = 2
+)
+
+// MagicFormula ranks companies by quality (high ROIC) and price (low EV/EBIT).
+// Each company gets a rank for both metrics; the lowest combined rank wins.
+type MagicFormula struct {
+	Dimension       string          // SF1 dimension to screen on (e.g. ART, MRQ)
+	MinMarketCap    decimal.Decimal // Exclude companies at or below this market cap
+	MaxDebtToEquity decimal.Decimal // Exclude companies at or above this D/E ratio
+	MaxPerSector    int             // Limit picks per sector for diversification
+	Universe        Universe        // Companies eligible for the screen
+}
+
+// NewMagicFormula creates a Magic Formula strategy with the default filters
+// (market cap > 500M, debt/equity < 0.5, max 2 picks per sector).
+func NewMagicFormula(dimension string) *MagicFormula {
+	return &MagicFormula{
+		Dimension:       dimension,
+		MinMarketCap:    decimal.NewFromInt(500_000_000),
+		MaxDebtToEquity: decimal.NewFromFloat(0.5),
+		MaxPerSector:    maxPicksPerSector,
+	}
+}
+
+// NewMagicFormulaIn creates a Magic Formula strategy restricted to a universe.
+func NewMagicFormulaIn(dimension string, universe Universe) *MagicFormula {
+	m := NewMagicFormula(dimension)
+	m.Universe = universe
+	return m
+}
+
+// Name implements Strategy.
+func (m *MagicFormula) Name() string {
+	if m.Universe != UniverseAll {
+		return fmt.Sprintf("Magic Formula (%s, %s)", m.Dimension, m.Universe)
+	}
+	return fmt.Sprintf("Magic Formula (%s)", m.Dimension)
+}
+
+// Dimensions implements FundamentalsStrategy.
+func (m *MagicFormula) Dimensions() []string {
+	return []string{m.Dimension}
+}
+
+// RunScreen implements Strategy. Only active companies are screened.
+func (m *MagicFormula) RunScreen(ctx context.Context, pool *pgxpool.Pool) ([]models.Recommendation, error) {
+	return m.screen(ctx, pool, time.Now(), true)
+}
+
+// RunScreenAsOf implements HistoricalStrategy.
+// Only fundamentals filed (date_key) on or before asOf are considered, and the
+// universe is every company listed on asOf, including ones delisted since.
+func (m *MagicFormula) RunScreenAsOf(ctx context.Context, pool *pgxpool.Pool, asOf time.Time) ([]models.Recommendation, error) {
+	return m.screen(ctx, pool, asOf, false)
+}
+
+func (m *MagicFormula) screen(ctx context.Context, pool *pgxpool.Pool, asOf time.Time, activeOnly bool) ([]models.Recommendation, error) {
+	size, err := portfolioSize(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := m.loadCandidates(ctx, db.NewRepository(pool), asOf, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := rankMagicFormula(candidates)
+	return selectTop(ranked, size, m.MaxPerSector), nil
+}
+
+// loadCandidates returns the point-in-time metrics of companies in the universe that
+// pass the market cap and leverage filters and have usable ROIC and EV/EBIT values.
+func (m *MagicFormula) loadCandidates(ctx context.Context, repo *db.Repository, asOf time.Time, activeOnly bool) ([]models.Recommendation, error) {
+	metrics, err := repo.GetFundamentalsAsOf(ctx, asOf, m.Dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	universe, err := universeTickers(ctx, repo, m.Universe, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	companies, err := repo.GetCompanies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []models.Recommendation
+	for _, fm := range metrics {
+		company, ok := companies[fm.Ticker]
+		if !ok || (activeOnly && !company.Active) {
+			continue
+		}
+		if !universe[fm.Ticker] {
+			continue
+		}
+		if !fm.MarketCap.Valid || !fm.MarketCap.Decimal.GreaterThan(m.MinMarketCap) {
+			continue
+		}
+		if !fm.DebtToEquity.Valid || !fm.DebtToEquity.Decimal.LessThan(m.MaxDebtToEquity) {
+			continue
+		}
+		// Negative EV/EBIT means negative earnings, which would otherwise rank as "cheapest"
+		if !fm.ROIC.Valid || !fm.EVEBIT.Valid || !fm.EVEBIT.Decimal.IsPositive() {
+			continue
+		}
+
+		candidates = append(candidates, models.Recommendation{
+			Ticker:    fm.Ticker,
+			Name:      company.Name,
+			Sector:    company.Sector,
+			DateKey:   fm.DateKey,
+			ROIC:      fm.ROIC.Decimal,
+			EVEBIT:    fm.EVEBIT.Decimal,
+			MarketCap: fm.MarketCap.Decimal,
+			Price:     fm.Price.Decimal,
+		})
+	}
+
+	return candidates, nil
+}
+
+// rankMagicFormula assigns ROIC (descending) and EV/EBIT (ascending) ranks, sums them
+// into a score and returns the candidates sorted by score (lowest first).
+func rankMagicFormula(candidates []models.Recommendation) []models.Recommendation {
+	roicRanks := rankBy(candidates, func(a, b models.Recommendation) int {
+		return b.ROIC.Cmp(a.ROIC)
+	})
+	evebitRanks := rankBy(candidates, func(a, b models.Recommendation) int {
+		return a.EVEBIT.Cmp(b.EVEBIT)
+	})
+
+	for i := range candidates {
+		candidates[i].Ranks = []models.RankComponent{
+			{Metric: "ROIC", Rank: roicRanks[i]},
+			{Metric: "EV/EBIT", Rank: evebitRanks[i]},
+		}
+		candidates[i].Score = roicRanks[i] + evebitRanks[i]
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		// Tie-break on quality, then ticker for a deterministic order
+		if c := b.ROIC.Cmp(a.ROIC); c != 0 {
+			return c < 0
+		}
+		return a.Ticker < b.Ticker
+	})
+
+	return candidates
+}
+
+// rankBy returns the 1-based rank of each item under the given ordering.
+// Equal items share the same rank ("1224" ranking).
+func rankBy(items []models.Recommendation, cmp func(a, b models.Recommendation) int) []int {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return cmp(items[order[i]], items[order[j]]) < 0
+	})
+
+	ranks := make([]int, len(items))
+	for pos, idx := range order {
+		if pos > 0 && cmp(items[order[pos-1]], items[idx]) == 0 {
+			ranks[idx] = ranks[order[pos-1]]
+			continue
+		}
+		ranks[idx] = pos + 1
+	}
+	return ranks
+}
+
+// selectTop picks the first size entries from a ranked list, skipping companies whose
+// sector already has maxPerSector picks. Picks are equally weighted.
+func selectTop(ranked []models.Recommendation, size, maxPerSector int) []models.Recommendation {
+	picks := make([]models.Recommendation, 0, size)
+	perSector := make(map[string]int)
+
+	for _, rec := range ranked {
+		if len(picks) >= size {
+			break
+		}
+		if maxPerSector > 0 && perSector[rec.Sector] >= maxPerSector {
+			continue
+		}
+		perSector[rec.Sector]++
+		picks = append(picks, rec)
+	}
+
+	if len(picks) == 0 {
+		return picks
+	}
+
+	weight := decimal.NewFromInt(1).DivRound(decimal.NewFromInt(int64(len(picks))), 4)
+	for i := range picks {
+		picks[i].Rank = i + 1
+		picks[i].Weight = weight
+	}
+
+	return picks
+}
+
+// portfolioSize reads the number of holdings to target from settings.portfolio_size.
+func portfolioSize(ctx context.Context, pool *pgxpool.Pool) (int, error) {
+	value, err := db.NewRepository(pool).GetSetting(ctx, "portfolio_size")
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultPortfolioSize, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading portfolio_size setting: %w", err)
+	}
+
+	size, err := strconv.Atoi(value)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid portfolio_size setting %q", value)
+	}
+
+	return size, nil
+}
@@ -0,0 +1,91 @@
+package analysis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+func candidate(ticker, sector string, roic, evebit float64) models.Recommendation {
+	return models.Recommendation{
+		Ticker: ticker,
+		Sector: sector,
+		ROIC:   decimal.NewFromFloat(roic),
+		EVEBIT: decimal.NewFromFloat(evebit),
+	}
+}
+
+func TestRankMagicFormula(t *testing.T) {
+	ranked := rankMagicFormula([]models.Recommendation{
+		candidate("A", "Healthcare", 0.30, 10),
+		candidate("E", "Technology", 0.10, 5), // Same metrics as D
+		candidate("B", "Technology", 0.30, 8),
+		candidate("C", "Energy", 0.20, 8),
+		candidate("D", "Technology", 0.10, 5),
+	})
+
+	tests := []struct {
+		ticker      string
+		roic, ev    int
+		score       int
+		description string
+	}{
+		{"B", 1, 3, 4, "shares the best ROIC with A"},
+		{"D", 4, 1, 5, "ties E on score and ROIC, first by ticker"},
+		{"E", 4, 1, 5, "second of the tie"},
+		{"A", 1, 5, 6, "ties C on score, first by ROIC"},
+		{"C", 3, 3, 6, "shares an EV/EBIT rank with B"},
+	}
+
+	if len(ranked) != len(tests) {
+		t.Fatalf("ranked %d candidates, want %d", len(ranked), len(tests))
+	}
+	for i, tt := range tests {
+		got := ranked[i]
+		if got.Ticker != tt.ticker {
+			t.Errorf("position %d = %s, want %s (%s)", i+1, got.Ticker, tt.ticker, tt.description)
+			continue
+		}
+		if got.Ranks[0].Rank != tt.roic || got.Ranks[1].Rank != tt.ev || got.Score != tt.score {
+			t.Errorf("%s: ranks %d+%d = %d, want %d+%d = %d", tt.ticker, got.Ranks[0].Rank, got.Ranks[1].Rank, got.Score, tt.roic, tt.ev, tt.score)
+		}
+	}
+}
+
+func TestSelectTop(t *testing.T) {
+	ranked := []models.Recommendation{
+		candidate("B", "Technology", 0, 0),
+		candidate("D", "Technology", 0, 0),
+		candidate("E", "Technology", 0, 0),
+		candidate("A", "Healthcare", 0, 0),
+		candidate("C", "Energy", 0, 0),
+	}
+
+	tests := []struct {
+		size, maxPerSector int
+		want               string
+		weight             string
+	}{
+		{4, 2, "[B D A C]", "0.25"}, // E is a third Technology pick
+		{4, 0, "[B D E A]", "0.25"}, // No sector cap
+		{3, 1, "[B A C]", "0.3333"},
+		{6, 2, "[B D A C]", "0.25"}, // Fewer eligible than the size
+	}
+
+	for _, tt := range tests {
+		picks := selectTop(ranked, tt.size, tt.maxPerSector)
+
+		tickers := make([]string, len(picks))
+		for i, p := range picks {
+			tickers[i] = p.Ticker
+			if p.Rank != i+1 || p.Weight.String() != tt.weight {
+				t.Errorf("size %d, cap %d: %s rank %d weight %s, want rank %d weight %s", tt.size, tt.maxPerSector, p.Ticker, p.Rank, p.Weight, i+1, tt.weight)
+			}
+		}
+		if got := fmt.Sprint(tickers); got != tt.want {
+			t.Errorf("size %d, cap %d: picks %s, want %s", tt.size, tt.maxPerSector, got, tt.want)
+		}
+	}
+}
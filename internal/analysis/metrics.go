@@ -0,0 +1,113 @@
+package analysis
+
+import (
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const tradingDaysPerYear = 252
+
+// ValuePoint is the value of a portfolio (or benchmark) on a given day.
+type ValuePoint struct {
+	Date  time.Time       `json:"date"`
+	Value decimal.Decimal `json:"value"`
+}
+
+// Performance summarizes a value series. Returns and drawdown are percentages.
+type Performance struct {
+	TotalReturn float64 `json:"total_return"`
+	CAGR        float64 `json:"cagr"`
+	MaxDrawdown float64 `json:"max_drawdown"` // Negative, e.g. -18.4
+	SharpeRatio float64 `json:"sharpe_ratio"` // Annualized, risk-free rate of 0
+}
+
+// computePerformance calculates total return, CAGR, max drawdown and Sharpe ratio
+// for a value series ordered by date.
+func computePerformance(series []ValuePoint) Performance {
+	if len(series) < 2 {
+		return Performance{}
+	}
+
+	first := series[0].Value.InexactFloat64()
+	last := series[len(series)-1].Value.InexactFloat64()
+	if first <= 0 {
+		return Performance{}
+	}
+
+	var perf Performance
+	growth := last / first
+	perf.TotalReturn = (growth - 1) * 100
+
+	years := series[len(series)-1].Date.Sub(series[0].Date).Hours() / 24 / 365.25
+	if years > 0 && growth > 0 {
+		perf.CAGR = (math.Pow(growth, 1/years) - 1) * 100
+	}
+
+	perf.MaxDrawdown = maxDrawdown(series) * 100
+	perf.SharpeRatio = sharpeRatio(dailyReturns(series))
+
+	return perf
+}
+
+// dailyReturns returns the period-over-period returns of a value series.
+func dailyReturns(series []ValuePoint) []float64 {
+	returns := make([]float64, 0, len(series))
+	for i := 1; i < len(series); i++ {
+		prev := series[i-1].Value.InexactFloat64()
+		if prev == 0 {
+			continue
+		}
+		returns = append(returns, series[i].Value.InexactFloat64()/prev-1)
+	}
+	return returns
+}
+
+// maxDrawdown returns the worst peak-to-trough decline as a (negative) fraction.
+func maxDrawdown(series []ValuePoint) float64 {
+	peak := 0.0
+	worst := 0.0
+	for _, p := range series {
+		v := p.Value.InexactFloat64()
+		if v > peak {
+			peak = v
+		}
+		if peak > 0 {
+			if dd := v/peak - 1; dd < worst {
+				worst = dd
+			}
+		}
+	}
+	return worst
+}
+
+// sharpeRatio returns the annualized Sharpe ratio of daily returns.
+func sharpeRatio(returns []float64) float64 {
+	mean, std := meanStd(returns)
+	if std == 0 {
+		return 0
+	}
+	return mean / std * math.Sqrt(tradingDaysPerYear)
+}
+
+// meanStd returns the mean and sample standard deviation of xs.
+func meanStd(xs []float64) (float64, float64) {
+	if len(xs) < 2 {
+		return 0, 0
+	}
+
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(len(xs))
+
+	variance := 0.0
+	for _, x := range xs {
+		variance += (x - mean) * (x - mean)
+	}
+	variance /= float64(len(xs) - 1)
+
+	return mean, math.Sqrt(variance)
+}
@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// series builds a value series on consecutive days from 2024-01-01.
+func series(values ...string) []ValuePoint {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]ValuePoint, len(values))
+	for i, v := range values {
+		points[i] = ValuePoint{Date: start.AddDate(0, 0, i), Value: decimal.RequireFromString(v)}
+	}
+	return points
+}
+
+func within(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestComputePerformance(t *testing.T) {
+	// +10%, -10%, +10%
+	s := series("100", "110", "99", "108.9")
+
+	perf := computePerformance(s)
+	if !within(perf.TotalReturn, 8.9, 1e-9) {
+		t.Errorf("total return = %v, want 8.9", perf.TotalReturn)
+	}
+	if !within(perf.MaxDrawdown, -10, 1e-9) {
+		t.Errorf("max drawdown = %v, want -10", perf.MaxDrawdown)
+	}
+	// Mean 1/30, sample standard deviation sqrt(0.04/3), annualized over 252 days
+	if !within(perf.SharpeRatio, 4.582576, 1e-6) {
+		t.Errorf("sharpe = %v, want 4.582576", perf.SharpeRatio)
+	}
+
+	// Two years of 8.9% total growth
+	s[len(s)-1].Date = s[0].Date.Add(2 * 365.25 * 24 * time.Hour)
+	if perf := computePerformance(s); !within(perf.CAGR, 4.355163, 1e-6) {
+		t.Errorf("CAGR = %v, want 4.355163", perf.CAGR)
+	}
+
+	if perf := computePerformance(series("100")); perf != (Performance{}) {
+		t.Errorf("single point = %+v, want zero", perf)
+	}
+}
+
+func TestCompareSeries(t *testing.T) {
+	// The portfolio moves twice as much as the benchmark every day: beta 2, alpha 0
+	benchmark := series("100", "101", "98.98", "101.9494", "101.9494")
+	portfolio := series("100", "102", "97.92", "103.7952", "103.7952")
+	// A benchmark-only day is ignored
+	extra := ValuePoint{Date: benchmark[len(benchmark)-1].Date.AddDate(0, 0, 1), Value: decimal.NewFromInt(200)}
+	benchmark = append(benchmark, extra)
+
+	cmp := compareSeries(portfolio, benchmark)
+
+	if cmp.Days != 5 {
+		t.Errorf("days = %d, want 5", cmp.Days)
+	}
+	if !within(cmp.Beta, 2, 1e-9) {
+		t.Errorf("beta = %v, want 2", cmp.Beta)
+	}
+	if !within(cmp.Alpha, 0, 1e-9) {
+		t.Errorf("alpha = %v, want 0", cmp.Alpha)
+	}
+	// Active returns equal the benchmark's: +1%, -2%, +3%, 0%
+	if !within(cmp.TrackingError, 33.045423, 1e-6) {
+		t.Errorf("tracking error = %v, want 33.045423", cmp.TrackingError)
+	}
+	if !within(cmp.InformationRatio, 3.812933, 1e-6) {
+		t.Errorf("information ratio = %v, want 3.812933", cmp.InformationRatio)
+	}
+	if !within(cmp.Delta.TotalReturn, 3.7952-1.9494, 1e-9) {
+		t.Errorf("total return delta = %v, want %v", cmp.Delta.TotalReturn, 3.7952-1.9494)
+	}
+}
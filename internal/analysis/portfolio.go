@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// Position is a holding valued at the latest close.
+type Position struct {
+	models.PortfolioHolding
+	AvgPrice     decimal.Decimal `json:"avg_price"`     // Cost basis per share
+	CurrentPrice decimal.Decimal `json:"current_price"` // Zero when no price is stored
+	MarketValue  decimal.Decimal `json:"market_value"`
+	Weight       decimal.Decimal `json:"weight"` // Fraction of total market value (0-1)
+	UnrealizedPL decimal.Decimal `json:"unrealized_pl"`
+	HasPrice     bool            `json:"has_price"`
+}
+
+// PortfolioSummary is the valued portfolio shown on the dashboard and portfolio page.
+type PortfolioSummary struct {
+	Positions    []Position      `json:"positions"`
+	TotalValue   decimal.Decimal `json:"total_value"`
+	TotalCost    decimal.Decimal `json:"total_cost"`
+	UnrealizedPL decimal.Decimal `json:"unrealized_pl"`
+}
+
+// SummarizePortfolio values every holding in the portfolio table at its latest close.
+func SummarizePortfolio(ctx context.Context, pool *pgxpool.Pool) (*PortfolioSummary, error) {
+	holdings, err := db.NewPortfolioRepository(pool).List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tickers := make([]string, 0, len(holdings))
+	for _, h := range holdings {
+		tickers = append(tickers, h.Ticker)
+	}
+
+	prices, err := db.NewRepository(pool).GetLatestCloses(ctx, tickers)
+	if err != nil {
+		return nil, err
+	}
+
+	return ValuePortfolio(holdings, prices), nil
+}
+
+// ValuePortfolio values each holding (lot) separately. Cost basis is the total
+// cost of the lot, so the average price is cost basis / shares. Holdings without
+// a price are kept with zero market value and are excluded from P/L.
+func ValuePortfolio(holdings []models.PortfolioHolding, prices map[string]decimal.Decimal) *PortfolioSummary {
+	summary := &PortfolioSummary{}
+
+	for _, h := range holdings {
+		p := Position{PortfolioHolding: h}
+		if h.SharesOwned.IsPositive() {
+			p.AvgPrice = h.CostBasis.Div(h.SharesOwned).Round(2)
+		}
+
+		if price, ok := prices[h.Ticker]; ok && price.IsPositive() {
+			p.HasPrice = true
+			p.CurrentPrice = price
+			p.MarketValue = h.SharesOwned.Mul(price).Round(2)
+			p.UnrealizedPL = p.MarketValue.Sub(h.CostBasis)
+
+			summary.TotalCost = summary.TotalCost.Add(h.CostBasis)
+			summary.UnrealizedPL = summary.UnrealizedPL.Add(p.UnrealizedPL)
+		}
+
+		summary.TotalValue = summary.TotalValue.Add(p.MarketValue)
+		summary.Positions = append(summary.Positions, p)
+	}
+
+	if summary.TotalValue.IsPositive() {
+		for i := range summary.Positions {
+			summary.Positions[i].Weight = summary.Positions[i].MarketValue.Div(summary.TotalValue)
+		}
+	}
+
+	return summary
+}
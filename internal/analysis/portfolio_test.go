@@ -0,0 +1,49 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+func TestValuePortfolio(t *testing.T) {
+	lot := func(ticker string, shares int64, cost string) models.PortfolioHolding {
+		return models.PortfolioHolding{Ticker: ticker, SharesOwned: decimal.NewFromInt(shares), CostBasis: decimal.RequireFromString(cost)}
+	}
+	holdings := []models.PortfolioHolding{
+		lot("AAPL", 10, "1500"),
+		lot("AAPL", 5, "1000"), // Second lot, valued on its own
+		lot("MSFT", 5, "1500"),
+		lot("DELISTED", 7, "700"), // No price
+	}
+
+	summary := ValuePortfolio(holdings, closesOf(map[string]int64{"AAPL": 200, "MSFT": 400}))
+
+	want := []struct {
+		avg, value, pl, weight string
+		hasPrice               bool
+	}{
+		{"150", "2000", "500", "0.4", true},
+		{"200", "1000", "0", "0.2", true},
+		{"300", "2000", "500", "0.4", true},
+		{"100", "0", "0", "0", false},
+	}
+
+	if len(summary.Positions) != len(want) {
+		t.Fatalf("got %d positions, want %d", len(summary.Positions), len(want))
+	}
+	for i, w := range want {
+		p := summary.Positions[i]
+		if p.AvgPrice.String() != w.avg || p.MarketValue.String() != w.value || p.UnrealizedPL.String() != w.pl ||
+			p.Weight.String() != w.weight || p.HasPrice != w.hasPrice {
+			t.Errorf("%s lot %d: avg %s value %s P/L %s weight %s priced %v, want avg %s value %s P/L %s weight %s priced %v",
+				p.Ticker, i+1, p.AvgPrice, p.MarketValue, p.UnrealizedPL, p.Weight, p.HasPrice, w.avg, w.value, w.pl, w.weight, w.hasPrice)
+		}
+	}
+
+	// The unpriced lot counts toward neither value nor cost
+	if summary.TotalValue.String() != "5000" || summary.TotalCost.String() != "4000" || summary.UnrealizedPL.String() != "1000" {
+		t.Errorf("totals = value %s cost %s P/L %s, want 5000, 4000, 1000", summary.TotalValue, summary.TotalCost, summary.UnrealizedPL)
+	}
+}
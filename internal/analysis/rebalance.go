@@ -0,0 +1,159 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// Rebalance actions, from the perspective of the current holding.
+const (
+	ActionBuy  = "Buy"  // Not held yet, has a target weight
+	ActionAdd  = "Add"  // Held below target weight
+	ActionTrim = "Trim" // Held above target weight
+	ActionSell = "Sell" // Held with a zero target weight
+	ActionHold = "Hold" // Less than one share away from target
+)
+
+// ErrInvalidTargets is returned when the holdings' target weights don't describe
+// one portfolio: lots of a ticker disagree, or the targets add up to more than 100%.
+var ErrInvalidTargets = errors.New("invalid target weights")
+
+// RebalanceAction is one line of the rebalancing table.
+type RebalanceAction struct {
+	Ticker        string          `json:"ticker"`
+	Shares        decimal.Decimal `json:"shares"`
+	Price         decimal.Decimal `json:"price"`
+	CurrentValue  decimal.Decimal `json:"current_value"`
+	CurrentWeight decimal.Decimal `json:"current_weight"` // Fraction of total value (0-1)
+	TargetWeight  decimal.Decimal `json:"target_weight"`  // Fraction of total value (0-1)
+	Action        string          `json:"action"`
+	Amount        decimal.Decimal `json:"amount"`   // Dollars to buy (+) or sell (-)
+	Quantity      int64           `json:"quantity"` // Whole shares to buy (+) or sell (-)
+}
+
+// RebalancePlan is the actionable trade list for a portfolio.
+type RebalancePlan struct {
+	Actions    []RebalanceAction `json:"actions"`
+	TotalValue decimal.Decimal   `json:"total_value"`
+}
+
+// RebalancePortfolio values the holdings in the portfolio table at the latest close
+// and calculates the trades needed to reach their target weights.
+func RebalancePortfolio(ctx context.Context, pool *pgxpool.Pool) (*RebalancePlan, error) {
+	holdings, err := db.NewPortfolioRepository(pool).List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tickers := make([]string, 0, len(holdings))
+	for _, h := range holdings {
+		tickers = append(tickers, h.Ticker)
+	}
+
+	prices, err := db.NewRepository(pool).GetLatestCloses(ctx, tickers)
+	if err != nil {
+		return nil, err
+	}
+
+	return CalculateRebalance(holdings, prices)
+}
+
+// CalculateRebalance compares each holding's current weight to its target weight:
+//
+//	current% = (shares × price) / total value
+//	amount   = (target% - current%) × total value
+//
+// Multiple lots of the same ticker are combined and must have the same target weight.
+// Returns ErrInvalidTargets if they don't or the targets sum to more than 1, and an
+// error if a holding has no price.
+func CalculateRebalance(holdings []models.PortfolioHolding, prices map[string]decimal.Decimal) (*RebalancePlan, error) {
+	byTicker := make(map[string]*RebalanceAction)
+	var order []string
+	var missing []string
+	var conflicting []string
+
+	for _, h := range holdings {
+		a, ok := byTicker[h.Ticker]
+		if !ok {
+			price, hasPrice := prices[h.Ticker]
+			if !hasPrice || !price.IsPositive() {
+				missing = append(missing, h.Ticker)
+				continue
+			}
+			a = &RebalanceAction{Ticker: h.Ticker, Price: price, TargetWeight: h.TargetWeight}
+			byTicker[h.Ticker] = a
+			order = append(order, h.Ticker)
+		} else if !h.TargetWeight.Equal(a.TargetWeight) && !slices.Contains(conflicting, h.Ticker) {
+			conflicting = append(conflicting, h.Ticker)
+		}
+
+		a.Shares = a.Shares.Add(h.SharesOwned)
+	}
+
+	if len(conflicting) > 0 {
+		return nil, fmt.Errorf("%w: lots of %s have different targets", ErrInvalidTargets, strings.Join(conflicting, ", "))
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no price for %s", strings.Join(missing, ", "))
+	}
+
+	targets := decimal.Zero
+	for _, ticker := range order {
+		targets = targets.Add(byTicker[ticker].TargetWeight)
+	}
+	if targets.GreaterThan(decimal.NewFromInt(1)) {
+		return nil, fmt.Errorf("%w: targets sum to %s%%, more than 100%%", ErrInvalidTargets, targets.Shift(2).String())
+	}
+
+	plan := &RebalancePlan{TotalValue: decimal.Zero}
+	for _, ticker := range order {
+		a := byTicker[ticker]
+		a.CurrentValue = a.Shares.Mul(a.Price)
+		plan.TotalValue = plan.TotalValue.Add(a.CurrentValue)
+	}
+
+	for _, ticker := range order {
+		a := byTicker[ticker]
+		if plan.TotalValue.IsPositive() {
+			a.CurrentWeight = a.CurrentValue.Div(plan.TotalValue)
+		}
+
+		a.Amount = a.TargetWeight.Sub(a.CurrentWeight).Mul(plan.TotalValue).Round(2)
+		a.Quantity = a.Amount.Div(a.Price).Truncate(0).IntPart()
+		a.Action = rebalanceAction(a)
+
+		plan.Actions = append(plan.Actions, *a)
+	}
+
+	// Largest trades first
+	sort.SliceStable(plan.Actions, func(i, j int) bool {
+		return plan.Actions[i].Amount.Abs().GreaterThan(plan.Actions[j].Amount.Abs())
+	})
+
+	return plan, nil
+}
+
+// rebalanceAction names the trade needed to move a holding to its target.
+func rebalanceAction(a *RebalanceAction) string {
+	switch {
+	case a.TargetWeight.IsZero() && a.Shares.IsPositive():
+		return ActionSell
+	case a.Quantity == 0:
+		return ActionHold
+	case a.Shares.IsZero():
+		return ActionBuy
+	case a.Quantity > 0:
+		return ActionAdd
+	default:
+		return ActionTrim
+	}
+}
@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+func holding(ticker string, shares int64, target string) models.PortfolioHolding {
+	return models.PortfolioHolding{
+		Ticker:       ticker,
+		SharesOwned:  decimal.NewFromInt(shares),
+		TargetWeight: decimal.RequireFromString(target),
+	}
+}
+
+func closesOf(closes map[string]int64) map[string]decimal.Decimal {
+	out := make(map[string]decimal.Decimal, len(closes))
+	for ticker, c := range closes {
+		out[ticker] = decimal.NewFromInt(c)
+	}
+	return out
+}
+
+func TestCalculateRebalance(t *testing.T) {
+	holdings := []models.PortfolioHolding{
+		holding("AAPL", 10, "0.49"),
+		holding("MSFT", 10, "0.2"),
+		holding("TSLA", 5, "0"),
+		holding("NVDA", 0, "0.1"),
+		holding("AAPL", 5, "0.49"), // Second lot
+		holding("GOOG", 10, "0.21"),
+	}
+	closes := closesOf(map[string]int64{"AAPL": 100, "MSFT": 200, "TSLA": 100, "NVDA": 30, "GOOG": 100})
+
+	plan, err := CalculateRebalance(holdings, closes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.TotalValue.Equal(decimal.NewFromInt(5000)) {
+		t.Errorf("total value = %s, want 5000", plan.TotalValue)
+	}
+
+	// Largest trades first; TSLA and NVDA tie and keep their holding order
+	want := []struct {
+		ticker, action, amount string
+		shares                 int64
+		quantity               int64
+	}{
+		{"MSFT", ActionTrim, "-1000", 10, -5},
+		{"AAPL", ActionAdd, "950", 15, 9}, // 9.5 shares, truncated
+		{"TSLA", ActionSell, "-500", 5, -5},
+		{"NVDA", ActionBuy, "500", 0, 16}, // 16.67 shares, truncated
+		{"GOOG", ActionHold, "50", 10, 0}, // Half a share from target
+	}
+
+	if len(plan.Actions) != len(want) {
+		t.Fatalf("got %d actions, want %d", len(plan.Actions), len(want))
+	}
+	for i, w := range want {
+		a := plan.Actions[i]
+		if a.Ticker != w.ticker || a.Action != w.action || a.Amount.String() != w.amount ||
+			!a.Shares.Equal(decimal.NewFromInt(w.shares)) || a.Quantity != w.quantity {
+			t.Errorf("action %d = %s %s %s (%s shares held, %d to trade), want %s %s %s (%d held, %d to trade)",
+				i+1, a.Action, a.Ticker, a.Amount, a.Shares, a.Quantity, w.action, w.ticker, w.amount, w.shares, w.quantity)
+		}
+	}
+}
+
+func TestCalculateRebalanceErrors(t *testing.T) {
+	closes := closesOf(map[string]int64{"AAPL": 100, "MSFT": 200})
+
+	tests := []struct {
+		name     string
+		holdings []models.PortfolioHolding
+		invalid  bool // ErrInvalidTargets
+	}{
+		{"conflicting lots", []models.PortfolioHolding{holding("AAPL", 10, "0.5"), holding("AAPL", 5, "0.3")}, true},
+		{"over 100%", []models.PortfolioHolding{holding("AAPL", 10, "0.6"), holding("MSFT", 5, "0.5")}, true},
+		{"no price", []models.PortfolioHolding{holding("AAPL", 10, "0.5"), holding("TSLA", 5, "0.5")}, false},
+	}
+
+	for _, tt := range tests {
+		_, err := CalculateRebalance(tt.holdings, closes)
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+			continue
+		}
+		if errors.Is(err, ErrInvalidTargets) != tt.invalid {
+			t.Errorf("%s: err = %v, ErrInvalidTargets %v", tt.name, err, tt.invalid)
+		}
+	}
+
+	// Lots that agree, summing to exactly 100%
+	ok := []models.PortfolioHolding{holding("AAPL", 10, "0.5"), holding("AAPL", 5, "0.5"), holding("MSFT", 5, "0.5")}
+	if _, err := CalculateRebalance(ok, closes); err != nil {
+		t.Errorf("matching lots: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+)
+
+// Strategy is a stock screen that proposes a portfolio from the data in the database.
+// Strategies are swappable so the dashboard can run e.g. Magic Formula or Dividend Yield
+// without code changes.
+type Strategy interface {
+	// Name is the unique, human readable name shown in the strategy dropdown.
+	Name() string
+	// RunScreen returns the proposed picks ordered by rank (best first).
+	RunScreen(ctx context.Context, db *pgxpool.Pool) ([]models.Recommendation, error)
+}
+
+// HistoricalStrategy is a Strategy that can also screen as of a past date,
+// using only data that was available on that date. Required for backtesting.
+type HistoricalStrategy interface {
+	Strategy
+	// RunScreenAsOf returns the picks the strategy would have made on asOf.
+	RunScreenAsOf(ctx context.Context, db *pgxpool.Pool, asOf time.Time) ([]models.Recommendation, error)
+}
+
+// FundamentalsStrategy is a Strategy that screens SF1 fundamentals, so the
+// nightly ingestion knows which dimensions to keep current.
+type FundamentalsStrategy interface {
+	Strategy
+	// Dimensions returns the SF1 dimensions the screen reads, e.g. ART.
+	Dimensions() []string
+}
+
+// Registry holds the strategies available to the application, keyed by name.
+// It preserves registration order so the dropdown lists strategies in a stable order.
+type Registry struct {
+	mu         sync.RWMutex
+	strategies map[string]Strategy
+	order      []string
+}
+
+// NewRegistry creates a registry containing the given strategies.
+// Panics if two strategies share a name, since that is a programming error.
+func NewRegistry(strategies ...Strategy) *Registry {
+	r := &Registry{
+		strategies: make(map[string]Strategy, len(strategies)),
+	}
+	for _, s := range strategies {
+		if err := r.Register(s); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// DefaultRegistry returns a registry with all built-in strategies.
+func DefaultRegistry() *Registry {
+	return NewRegistry(
+		NewMagicFormula("ART"),
+		NewMagicFormula("MRQ"),
+		NewMagicFormulaIn("ART", UniverseSP500),
+	)
+}
+
+// Register adds a strategy to the registry.
+func (r *Registry) Register(s Strategy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := s.Name()
+	if _, exists := r.strategies[name]; exists {
+		return fmt.Errorf("strategy %q already registered", name)
+	}
+
+	r.strategies[name] = s
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Get returns the strategy with the given name.
+func (r *Registry) Get(name string) (Strategy, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.strategies[name]
+	return s, ok
+}
+
+// Names returns the names of all registered strategies in registration order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, len(r.order))
+	copy(names, r.order)
+	return names
+}
+
+// Dimensions returns the SF1 dimensions read by the registered strategies,
+// without duplicates, in registration order.
+func (r *Registry) Dimensions() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var dimensions []string
+	seen := make(map[string]bool)
+	for _, name := range r.order {
+		fs, ok := r.strategies[name].(FundamentalsStrategy)
+		if !ok {
+			continue
+		}
+		for _, d := range fs.Dimensions() {
+			if !seen[d] {
+				seen[d] = true
+				dimensions = append(dimensions, d)
+			}
+		}
+	}
+	return dimensions
+}
@@ -0,0 +1,38 @@
+package analysis
+
+import (
+	"context"
+	"time"
+
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+)
+
+// Universe restricts which companies a strategy may pick from.
+type Universe string
+
+const (
+	UniverseAll   Universe = ""        // Every company listed on the screen date
+	UniverseSP500 Universe = "S&P 500" // S&P 500 constituents as of the screen date
+)
+
+// universeTickers returns the set of tickers in the universe on asOf.
+func universeTickers(ctx context.Context, repo *db.Repository, u Universe, asOf time.Time) (map[string]bool, error) {
+	var tickers []string
+	var err error
+
+	switch u {
+	case UniverseSP500:
+		tickers, err = repo.GetSP500ConstituentsAt(ctx, asOf)
+	default:
+		tickers, err = repo.GetUniverseAt(ctx, asOf)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool, len(tickers))
+	for _, t := range tickers {
+		set[t] = true
+	}
+	return set, nil
+}
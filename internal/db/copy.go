@@ -0,0 +1,327 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+)
+
+// copyBatchSize is the rows per COPY transaction. Much larger than dbBatchSize since
+// a COPY costs one round trip, but a failure still rejects the whole batch.
+const copyBatchSize = 50000
+
+// copyTarget describes a table loaded by copyMerge.
+type copyTarget struct {
+	table   string
+	key     []string // Conflict key, a unique constraint on table
+	columns []string // Copied columns, including the key
+	touch   string   // Extra assignment on update, e.g. "updated_at = NOW()"
+	guard   string   // Extra condition for an update, see newerOnly
+}
+
+// newerOnly returns t without updates that would replace a row with an older
+// fetch of it, by last_updated. Skipped rows count as unchanged.
+func (t copyTarget) newerOnly() copyTarget {
+	t.guard = fmt.Sprintf("(%[1]s.last_updated IS NULL OR EXCLUDED.last_updated >= %[1]s.last_updated)", t.table)
+	return t
+}
+
+var (
+	financialMetricsCopy = copyTarget{
+		table: "financial_metrics",
+		key:   []string{"ticker", "date_key", "dimension"},
+		columns: []string{
+			"ticker", "dimension", "date_key", "report_period",
+			"revenue", "net_income", "ebitda", "fcf",
+			"roic", "pe_ratio", "ev_ebit", "pb_ratio", "debt_to_equity",
+			"market_cap", "enterprise_value", "price",
+			"last_updated",
+		},
+		touch: "updated_at = NOW()",
+	}
+	dailyPricesCopy = copyTarget{
+		table: "daily_prices",
+		key:   []string{"ticker", "date"},
+		columns: []string{
+			"ticker", "date", "open", "high", "low", "close", "volume",
+			"dividends", "close_unadj", "market_cap", "enterprise_value",
+			"pe_ratio", "pb_ratio", "last_updated",
+		},
+	}
+	equityPricesCopy = copyTarget{
+		table: "equity_prices",
+		key:   []string{"ticker", "date"},
+		columns: []string{
+			"ticker", "date", "open", "high", "low", "close", "volume",
+			"close_adj", "close_unadj", "last_updated",
+		},
+	}
+	fundPricesCopy = copyTarget{
+		table: "fund_prices",
+		key:   []string{"ticker", "date"},
+		columns: []string{
+			"ticker", "date", "open", "high", "low", "close", "volume",
+			"close_adj", "close_unadj", "last_updated",
+		},
+	}
+)
+
+// mergeSQL builds the set-based merge from the staging table. DISTINCT ON keeps one
+// row per key since ON CONFLICT cannot update a row twice in one statement, and the
+// WHERE clause skips rows whose values are unchanged so they are neither rewritten
+// nor counted as updated. xmax is 0 only for rows this statement inserted.
+func (t copyTarget) mergeSQL(staging string) string {
+	var set, changed, excluded []string
+	isKey := make(map[string]bool)
+	for _, k := range t.key {
+		isKey[k] = true
+	}
+	for _, c := range t.columns {
+		if isKey[c] {
+			continue
+		}
+		set = append(set, fmt.Sprintf("%s = EXCLUDED.%s", c, c))
+		changed = append(changed, t.table+"."+c)
+		excluded = append(excluded, "EXCLUDED."+c)
+	}
+	if t.touch != "" {
+		set = append(set, t.touch)
+	}
+
+	cols := strings.Join(t.columns, ", ")
+	key := strings.Join(t.key, ", ")
+	guard := ""
+	if t.guard != "" {
+		guard = " AND " + t.guard
+	}
+
+	return fmt.Sprintf(`
+		WITH merged AS (
+			INSERT INTO %[1]s (%[2]s)
+			SELECT DISTINCT ON (%[3]s) %[2]s FROM %[4]s ORDER BY %[3]s
+			ON CONFLICT (%[3]s) DO UPDATE SET %[5]s
+			WHERE (%[6]s) IS DISTINCT FROM (%[7]s)%[8]s
+			RETURNING (%[1]s.xmax = 0) AS inserted
+		)
+		SELECT
+			(SELECT COUNT(*) FROM (SELECT DISTINCT %[3]s FROM %[4]s) k),
+			COUNT(*) FILTER (WHERE inserted),
+			COUNT(*) FILTER (WHERE NOT inserted)
+		FROM merged
+	`, t.table, cols, key, staging, strings.Join(set, ", "), strings.Join(changed, ", "), strings.Join(excluded, ", "), guard)
+}
+
+// copyMerge loads rows into a temporary staging table with COPY and merges them
+// into the target in one transaction. Returns the distinct keys staged and how
+// many of them were inserted and updated; the rest were unchanged.
+func (r *Repository) copyMerge(ctx context.Context, t copyTarget, rows [][]any) (staged, inserted, updated int, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("beginning %s copy: %w", t.table, err)
+	}
+	defer tx.Rollback(ctx) // No-op after commit
+
+	staging := "staging_" + t.table
+	cols := strings.Join(t.columns, ", ")
+
+	// CREATE TABLE AS copies column types but not constraints or defaults
+	_, err = tx.Exec(ctx, fmt.Sprintf(
+		"CREATE TEMP TABLE %s ON COMMIT DROP AS SELECT %s FROM %s WITH NO DATA",
+		staging, cols, t.table,
+	))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("creating %s: %w", staging, err)
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{staging}, t.columns, pgx.CopyFromRows(rows)); err != nil {
+		return 0, 0, 0, fmt.Errorf("copying into %s: %w", staging, err)
+	}
+
+	if err := tx.QueryRow(ctx, t.mergeSQL(staging)).Scan(&staged, &inserted, &updated); err != nil {
+		return 0, 0, 0, fmt.Errorf("merging %s: %w", t.table, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, 0, fmt.Errorf("committing %s copy: %w", t.table, err)
+	}
+
+	return staged, inserted, updated, nil
+}
+
+// copyRows runs copyMerge in batches of copyBatchSize. encode converts rows[i] to
+// copy values, sanitizing decimals into the batch's stats; ticker names the row
+// for rejections when a batch fails.
+func (r *Repository) copyRows(ctx context.Context, t copyTarget, n int, encode func(i int, s *decimalSanitizer) []any, ticker func(i int) string, row func(i int) any) (UpsertStats, error) {
+	var stats UpsertStats
+	if n == 0 {
+		return stats, nil
+	}
+
+	limits, err := r.columnLimits(ctx, t.table)
+	if err != nil {
+		return stats, err
+	}
+
+	for i := 0; i < n; i += copyBatchSize {
+		end := i + copyBatchSize
+		if end > n {
+			end = n
+		}
+
+		batch := UpsertStats{}
+		s := &decimalSanitizer{table: t.table, limits: limits, stats: &batch}
+		values := make([][]any, 0, end-i)
+		for j := i; j < end; j++ {
+			values = append(values, encode(j, s))
+		}
+
+		staged, inserted, updated, err := r.copyMerge(ctx, t, values)
+		if err != nil {
+			log.Printf("Error in %s copy %d-%d: %v", t.table, i, end, err)
+			stats.fail(i, end-i, err)
+			for j := i; j < end; j++ {
+				stats.reject(t.table, ticker(j), "", err.Error(), j, row(j))
+			}
+			continue // Continue with next batch instead of failing entirely
+		}
+
+		// Rejections from encode already carry absolute indexes
+		stats.merge(batch, 0)
+		stats.Rows += staged
+		stats.Inserted += inserted
+		stats.Updated += updated
+		stats.Unchanged += staged - inserted - updated
+	}
+
+	log.Printf("Copied %d %s rows: %d inserted, %d updated, %d unchanged", stats.Rows, t.table, stats.Inserted, stats.Updated, stats.Unchanged)
+
+	if len(stats.Errors) > 0 && stats.Rows == 0 {
+		return stats, stats.Errors[len(stats.Errors)-1]
+	}
+
+	return stats, nil
+}
+
+// CopyFinancialMetrics is the bulk equivalent of UpsertFinancialMetrics: rows are
+// COPYed into a staging table and merged with one statement per batch.
+func (r *Repository) CopyFinancialMetrics(ctx context.Context, rows []ingest.SF1Row) (UpsertStats, error) {
+	return r.copyFinancialMetrics(ctx, financialMetricsCopy, rows)
+}
+
+// RestoreFinancialMetrics stores rows like CopyFinancialMetrics, but never over a
+// row updated since they were fetched, for retrying dead letters.
+func (r *Repository) RestoreFinancialMetrics(ctx context.Context, rows []ingest.SF1Row) (UpsertStats, error) {
+	return r.copyFinancialMetrics(ctx, financialMetricsCopy.newerOnly(), rows)
+}
+
+func (r *Repository) copyFinancialMetrics(ctx context.Context, t copyTarget, rows []ingest.SF1Row) (UpsertStats, error) {
+	return r.copyRows(ctx, t, len(rows),
+		func(i int, s *decimalSanitizer) []any {
+			row := rows[i]
+			sanitize := s.row(i, row.Ticker, row)
+
+			reportPeriod := row.DateKey
+			if row.ReportPeriod != nil {
+				reportPeriod = *row.ReportPeriod
+			}
+
+			return []any{
+				row.Ticker, row.Dimension, row.DateKey, reportPeriod,
+				sanitize(row.Revenue, "revenue"),
+				sanitize(row.NetIncome, "net_income"),
+				sanitize(row.EBITDA, "ebitda"),
+				sanitize(row.FCF, "fcf"),
+				sanitize(row.ROIC, "roic"),
+				sanitize(row.PE, "pe_ratio"),
+				sanitize(row.EVEBIT, "ev_ebit"),
+				sanitize(row.PB, "pb_ratio"),
+				sanitize(row.DE, "debt_to_equity"),
+				sanitize(row.MarketCap, "market_cap"),
+				sanitize(row.EV, "enterprise_value"),
+				sanitize(row.Price, "price"),
+				row.LastUpdated,
+			}
+		},
+		func(i int) string { return rows[i].Ticker },
+		func(i int) any { return rows[i] },
+	)
+}
+
+// CopyDailyPrices is the bulk equivalent of UpsertDailyPrices, for large backfills.
+func (r *Repository) CopyDailyPrices(ctx context.Context, rows []ingest.DailyRow) (UpsertStats, error) {
+	return r.copyDailyPrices(ctx, dailyPricesCopy, rows)
+}
+
+// RestoreDailyPrices stores rows like CopyDailyPrices, but never over a row
+// updated since they were fetched, for retrying dead letters.
+func (r *Repository) RestoreDailyPrices(ctx context.Context, rows []ingest.DailyRow) (UpsertStats, error) {
+	return r.copyDailyPrices(ctx, dailyPricesCopy.newerOnly(), rows)
+}
+
+func (r *Repository) copyDailyPrices(ctx context.Context, t copyTarget, rows []ingest.DailyRow) (UpsertStats, error) {
+	return r.copyRows(ctx, t, len(rows),
+		func(i int, s *decimalSanitizer) []any {
+			row := rows[i]
+			sanitize := s.row(i, row.Ticker, row)
+
+			return []any{
+				row.Ticker, row.Date,
+				sanitize(row.Open, "open"), sanitize(row.High, "high"), sanitize(row.Low, "low"), sanitize(row.Close, "close"),
+				row.Volume,
+				sanitize(row.Dividends, "dividends"), sanitize(row.CloseUnadj, "close_unadj"),
+				sanitize(row.MarketCap, "market_cap"),
+				sanitize(row.EV, "enterprise_value"),
+				sanitize(row.PE, "pe_ratio"),
+				sanitize(row.PB, "pb_ratio"),
+				row.LastUpdated,
+			}
+		},
+		func(i int) string { return rows[i].Ticker },
+		func(i int) any { return rows[i] },
+	)
+}
+
+// CopyEquityPrices stores SHARADAR/SEP rows in equity_prices.
+func (r *Repository) CopyEquityPrices(ctx context.Context, rows []ingest.PriceRow) (UpsertStats, error) {
+	return r.copyPrices(ctx, equityPricesCopy, rows)
+}
+
+// CopyFundPrices stores SHARADAR/SFP rows in fund_prices.
+func (r *Repository) CopyFundPrices(ctx context.Context, rows []ingest.PriceRow) (UpsertStats, error) {
+	return r.copyPrices(ctx, fundPricesCopy, rows)
+}
+
+// RestoreEquityPrices stores rows like CopyEquityPrices, but never over a row
+// updated since they were fetched, for retrying dead letters.
+func (r *Repository) RestoreEquityPrices(ctx context.Context, rows []ingest.PriceRow) (UpsertStats, error) {
+	return r.copyPrices(ctx, equityPricesCopy.newerOnly(), rows)
+}
+
+// RestoreFundPrices is RestoreEquityPrices for fund_prices.
+func (r *Repository) RestoreFundPrices(ctx context.Context, rows []ingest.PriceRow) (UpsertStats, error) {
+	return r.copyPrices(ctx, fundPricesCopy.newerOnly(), rows)
+}
+
+// copyPrices copies SEP or SFP rows, which share their columns, into t.
+func (r *Repository) copyPrices(ctx context.Context, t copyTarget, rows []ingest.PriceRow) (UpsertStats, error) {
+	return r.copyRows(ctx, t, len(rows),
+		func(i int, s *decimalSanitizer) []any {
+			row := rows[i]
+			sanitize := s.row(i, row.Ticker, row)
+
+			return []any{
+				row.Ticker, row.Date,
+				sanitize(row.Open, "open"), sanitize(row.High, "high"), sanitize(row.Low, "low"), sanitize(row.Close, "close"),
+				row.Volume,
+				sanitize(row.CloseAdj, "close_adj"), sanitize(row.CloseUnadj, "close_unadj"),
+				row.LastUpdated,
+			}
+		},
+		func(i int) string { return rows[i].Ticker },
+		func(i int) any { return rows[i] },
+	)
+}
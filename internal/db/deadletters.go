@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+)
+
+// DeadLetterFilter selects unresolved dead letters. Zero fields match everything.
+type DeadLetterFilter struct {
+	RunID int64
+	Table string
+	Limit int
+}
+
+// DeadLetterRepository handles database operations for the ingest dead-letter table.
+type DeadLetterRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewDeadLetterRepository creates a new dead-letter repository.
+func NewDeadLetterRepository(pool *pgxpool.Pool) *DeadLetterRepository {
+	return &DeadLetterRepository{pool: pool}
+}
+
+// AddDeadLetters stores rejected rows against a run.
+func (r *DeadLetterRepository) AddDeadLetters(ctx context.Context, runID int64, rejected []RejectedRow) error {
+	if len(rejected) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, rej := range rejected {
+		raw, err := json.Marshal(rej.Row)
+		if err != nil {
+			return fmt.Errorf("encoding dead-letter row: %w", err)
+		}
+		batch.Queue(`
+			INSERT INTO ingest_dead_letters (run_id, target_table, ticker, field, reason, raw_row)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, runID, rej.Table, rej.Ticker, rej.Field, rej.Reason, raw)
+	}
+
+	br := r.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for range rejected {
+		if _, err := br.Exec(); err != nil {
+			return fmt.Errorf("inserting dead letter: %w", err)
+		}
+	}
+
+	return nil
+}
+
+const deadLetterColumns = `
+	id, run_id, target_table, ticker, field, reason, raw_row,
+	retry_count, COALESCE(last_retry_error, ''), created_at, last_retry_at, resolved_at`
+
+// ListDeadLetters returns unresolved dead letters matching the filter, oldest first.
+func (r *DeadLetterRepository) ListDeadLetters(ctx context.Context, f DeadLetterFilter) ([]models.DeadLetter, error) {
+	where := []string{"resolved_at IS NULL"}
+	var args []any
+	if f.RunID != 0 {
+		args = append(args, f.RunID)
+		where = append(where, fmt.Sprintf("run_id = $%d", len(args)))
+	}
+	if f.Table != "" {
+		args = append(args, f.Table)
+		where = append(where, fmt.Sprintf("target_table = $%d", len(args)))
+	}
+
+	query := "SELECT " + deadLetterColumns + " FROM ingest_dead_letters WHERE " + strings.Join(where, " AND ") + " ORDER BY id"
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var letters []models.DeadLetter
+	for rows.Next() {
+		var l models.DeadLetter
+		if err := rows.Scan(
+			&l.ID, &l.RunID, &l.Table, &l.Ticker, &l.Field, &l.Reason, &l.Row,
+			&l.RetryCount, &l.LastRetryError, &l.CreatedAt, &l.LastRetryAt, &l.ResolvedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scanning dead letter: %w", err)
+		}
+		letters = append(letters, l)
+	}
+
+	return letters, rows.Err()
+}
+
+// CountUnresolved returns the number of dead letters not yet retried successfully.
+func (r *DeadLetterRepository) CountUnresolved(ctx context.Context) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM ingest_dead_letters WHERE resolved_at IS NULL").Scan(&count)
+	return count, err
+}
+
+// ResolveDeadLetters marks dead letters whose rows a retry stored as fetched.
+func (r *DeadLetterRepository) ResolveDeadLetters(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.pool.Exec(ctx, `
+		UPDATE ingest_dead_letters SET
+			retry_count = retry_count + 1,
+			last_retry_at = NOW(),
+			resolved_at = NOW()
+		WHERE id = ANY($1)
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("resolving dead letters: %w", err)
+	}
+	return nil
+}
+
+// RetryFailed records a retry that rejected the row again.
+func (r *DeadLetterRepository) RetryFailed(ctx context.Context, id int64, reason string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE ingest_dead_letters SET
+			retry_count = retry_count + 1,
+			last_retry_at = NOW(),
+			last_retry_error = $2
+		WHERE id = $1
+	`, id, reason)
+	if err != nil {
+		return fmt.Errorf("recording dead-letter retry: %w", err)
+	}
+	return nil
+}
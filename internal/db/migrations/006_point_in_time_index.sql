@@ -0,0 +1,8 @@
+-- +goose Up
+
+-- Supports "latest row per ticker filed on or before a date" lookups
+CREATE INDEX idx_financial_metrics_dimension_ticker_date_key
+    ON financial_metrics(dimension, ticker, date_key DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_financial_metrics_dimension_ticker_date_key;
@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- S&P 500 membership history from SHARADAR/SP500.
+-- 'added'/'removed' rows are historical events; 'current' rows list today's constituents.
+CREATE TABLE sp500_membership (
+    id SERIAL PRIMARY KEY,
+    date DATE NOT NULL,
+    action TEXT NOT NULL,
+    ticker TEXT NOT NULL,
+    name TEXT,
+    contra_ticker TEXT,
+    contra_name TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(date, action, ticker)
+);
+
+CREATE INDEX idx_sp500_membership_ticker_date ON sp500_membership(ticker, date);
+
+-- +goose Down
+DROP TABLE IF EXISTS sp500_membership;
@@ -0,0 +1,18 @@
+-- +goose Up
+
+-- Keep delisted companies with their listing window so history stays queryable.
+-- `active` only decides what is screened today; the listing dates decide the
+-- universe on a historical date.
+ALTER TABLE companies ADD COLUMN is_delisted BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE companies ADD COLUMN first_price_date DATE;
+ALTER TABLE companies ADD COLUMN delisted_date DATE;
+
+UPDATE companies SET is_delisted = NOT COALESCE(active, TRUE);
+
+CREATE INDEX idx_companies_listing ON companies(first_price_date, delisted_date);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_companies_listing;
+ALTER TABLE companies DROP COLUMN IF EXISTS delisted_date;
+ALTER TABLE companies DROP COLUMN IF EXISTS first_price_date;
+ALTER TABLE companies DROP COLUMN IF EXISTS is_delisted;
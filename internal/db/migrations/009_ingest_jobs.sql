@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Background ingestion jobs. Progress is written as batches complete so a job
+-- can be polled from another request and its outcome survives a restart.
+CREATE TABLE ingest_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,                      -- 'fundamentals', 'daily', 'benchmarks', ...
+    params JSONB NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'running',  -- 'running', 'succeeded', 'failed', 'cancelled'
+    message TEXT,
+    batches_done INTEGER NOT NULL DEFAULT 0,
+    rows_upserted BIGINT NOT NULL DEFAULT 0,
+    error_count INTEGER NOT NULL DEFAULT 0,
+    errors TEXT[] NOT NULL DEFAULT '{}',     -- First errors only, see error_count for the total
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_ingest_jobs_status ON ingest_jobs(status);
+
+-- +goose Down
+DROP TABLE IF EXISTS ingest_jobs;
@@ -0,0 +1,13 @@
+-- +goose Up
+
+-- Nightly incremental ingestion, see internal/scheduler.
+-- Standard 5-field cron (minute hour day-of-month month day-of-week) in ingest_timezone.
+-- Runs falling on weekends or US market holidays are skipped.
+INSERT INTO settings (key, value) VALUES
+    ('ingest_schedule', '0 19 * * 1-5'),
+    ('ingest_timezone', 'America/New_York'),
+    ('ingest_schedule_enabled', 'true')
+ON CONFLICT (key) DO NOTHING;
+
+-- +goose Down
+DELETE FROM settings WHERE key IN ('ingest_schedule', 'ingest_timezone', 'ingest_schedule_enabled');
@@ -0,0 +1,24 @@
+-- +goose Up
+
+-- Every ingestion step is now recorded, not only background jobs, so the table
+-- becomes a run history. A background job's ID is its run ID.
+ALTER TABLE ingest_jobs RENAME TO ingest_runs;
+ALTER INDEX idx_ingest_jobs_status RENAME TO idx_ingest_runs_status;
+
+ALTER TABLE ingest_runs ADD COLUMN endpoint TEXT NOT NULL DEFAULT '';      -- API path, or 'scheduler'
+ALTER TABLE ingest_runs ADD COLUMN since TIMESTAMP;                         -- Earliest incremental watermark used, NULL = all history
+ALTER TABLE ingest_runs ADD COLUMN watermark_groups INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE ingest_runs ADD COLUMN values_overflowed INTEGER NOT NULL DEFAULT 0; -- Stored as NULL, see sanitizeDecimal
+ALTER TABLE ingest_runs ADD COLUMN rows_failed INTEGER NOT NULL DEFAULT 0;       -- Rows in failed database batches
+
+CREATE INDEX idx_ingest_runs_started_at ON ingest_runs(started_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_ingest_runs_started_at;
+ALTER TABLE ingest_runs DROP COLUMN IF EXISTS rows_failed;
+ALTER TABLE ingest_runs DROP COLUMN IF EXISTS values_overflowed;
+ALTER TABLE ingest_runs DROP COLUMN IF EXISTS watermark_groups;
+ALTER TABLE ingest_runs DROP COLUMN IF EXISTS since;
+ALTER TABLE ingest_runs DROP COLUMN IF EXISTS endpoint;
+ALTER INDEX idx_ingest_runs_status RENAME TO idx_ingest_jobs_status;
+ALTER TABLE ingest_runs RENAME TO ingest_jobs;
@@ -0,0 +1,24 @@
+-- +goose Up
+
+-- Values nulled by sanitizeDecimal and rows of failed database batches, kept with
+-- the row as fetched so they can be retried once the schema is fixed.
+CREATE TABLE ingest_dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT REFERENCES ingest_runs(id) ON DELETE SET NULL,
+    target_table TEXT NOT NULL,              -- 'financial_metrics', 'daily_prices', 'benchmark_prices'
+    ticker TEXT NOT NULL,
+    field TEXT NOT NULL DEFAULT '',          -- Overflowing column, empty for failed batches
+    reason TEXT NOT NULL,
+    raw_row JSONB NOT NULL,
+    retry_count INTEGER NOT NULL DEFAULT 0,
+    last_retry_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_retry_at TIMESTAMPTZ,
+    resolved_at TIMESTAMPTZ                  -- Set once a retry stores the row as fetched
+);
+
+CREATE INDEX idx_ingest_dead_letters_unresolved ON ingest_dead_letters(target_table, id) WHERE resolved_at IS NULL;
+CREATE INDEX idx_ingest_dead_letters_run_id ON ingest_dead_letters(run_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS ingest_dead_letters;
@@ -0,0 +1,82 @@
+-- +goose Up
+
+-- Split-adjusted equity prices from SHARADAR/SEP. daily_prices keeps the
+-- SHARADAR/DAILY valuation metrics, which have no OHLC columns.
+CREATE TABLE equity_prices (
+    id BIGSERIAL PRIMARY KEY,
+    ticker TEXT NOT NULL REFERENCES companies(ticker),
+    date DATE NOT NULL,
+    open DECIMAL(18, 6),
+    high DECIMAL(18, 6),
+    low DECIMAL(18, 6),
+    close DECIMAL(18, 6),          -- Split-adjusted
+    volume BIGINT,
+    close_adj DECIMAL(18, 6),      -- Split and dividend adjusted
+    close_unadj DECIMAL(18, 6),
+    last_updated TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(ticker, date)
+);
+
+CREATE INDEX idx_equity_prices_date ON equity_prices(date);
+
+-- Fund prices (ETFs, ETNs, CEFs) from SHARADAR/SFP. Funds aren't in companies,
+-- so there is no foreign key; benchmarks are priced from here.
+CREATE TABLE fund_prices (
+    id BIGSERIAL PRIMARY KEY,
+    ticker TEXT NOT NULL,
+    date DATE NOT NULL,
+    open DECIMAL(18, 6),
+    high DECIMAL(18, 6),
+    low DECIMAL(18, 6),
+    close DECIMAL(18, 6),
+    volume BIGINT,
+    close_adj DECIMAL(18, 6),
+    close_unadj DECIMAL(18, 6),
+    last_updated TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(ticker, date)
+);
+
+CREATE INDEX idx_fund_prices_date ON fund_prices(date);
+
+-- benchmark_prices was filled from SHARADAR/DAILY, which doesn't cover funds;
+-- keep whatever it holds until the next SFP ingestion replaces it
+INSERT INTO fund_prices (ticker, date, open, high, low, close, volume, close_unadj, last_updated)
+SELECT ticker, date, open, high, low, close, volume, close_unadj, last_updated
+FROM benchmark_prices
+ON CONFLICT (ticker, date) DO NOTHING;
+
+UPDATE ingest_dead_letters SET target_table = 'fund_prices' WHERE target_table = 'benchmark_prices';
+
+DROP TABLE benchmark_prices;
+
+-- +goose Down
+CREATE TABLE benchmark_prices (
+    id SERIAL PRIMARY KEY,
+    ticker TEXT NOT NULL REFERENCES benchmarks(ticker),
+    date DATE NOT NULL,
+    open DECIMAL(18, 6),
+    high DECIMAL(18, 6),
+    low DECIMAL(18, 6),
+    close DECIMAL(18, 6),
+    volume BIGINT,
+    dividends DECIMAL(18, 6),
+    close_unadj DECIMAL(18, 6),
+    last_updated TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(ticker, date)
+);
+
+CREATE INDEX idx_benchmark_prices_ticker ON benchmark_prices(ticker);
+CREATE INDEX idx_benchmark_prices_date ON benchmark_prices(date);
+
+INSERT INTO benchmark_prices (ticker, date, open, high, low, close, volume, close_unadj, last_updated)
+SELECT ticker, date, open, high, low, close, volume, close_unadj, last_updated
+FROM fund_prices
+WHERE ticker IN (SELECT ticker FROM benchmarks);
+
+UPDATE ingest_dead_letters SET target_table = 'benchmark_prices' WHERE target_table = 'fund_prices';
+
+DROP TABLE IF EXISTS fund_prices;
+DROP TABLE IF EXISTS equity_prices;
@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- Nasdaq Data Link calls and rows per UTC day, checked against the daily quota
+-- before each call
+CREATE TABLE api_usage (
+    day DATE PRIMARY KEY,
+    calls BIGINT NOT NULL DEFAULT 0,
+    rows_returned BIGINT NOT NULL DEFAULT 0,
+    throttled BIGINT NOT NULL DEFAULT 0,      -- 429 responses
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_usage;
@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- Schema drift and other problems that didn't stop a run, each recorded once
+ALTER TABLE ingest_runs ADD COLUMN warnings TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE ingest_runs DROP COLUMN IF EXISTS warnings;
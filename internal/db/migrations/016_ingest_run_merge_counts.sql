@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- How the rows upserted split between new, changed and identical rows. Only the
+-- COPY path can tell them apart, so runs upserting row by row leave them at 0.
+ALTER TABLE ingest_runs ADD COLUMN rows_inserted BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE ingest_runs ADD COLUMN rows_updated BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE ingest_runs ADD COLUMN rows_unchanged BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE ingest_runs DROP COLUMN IF EXISTS rows_unchanged;
+ALTER TABLE ingest_runs DROP COLUMN IF EXISTS rows_updated;
+ALTER TABLE ingest_runs DROP COLUMN IF EXISTS rows_inserted;
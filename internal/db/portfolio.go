@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+)
+
+// ErrHoldingNotFound is returned when a portfolio holding does not exist.
+var ErrHoldingNotFound = errors.New("holding not found")
+
+// ErrUnknownTicker is returned when a holding's ticker is not in companies.
+var ErrUnknownTicker = errors.New("unknown ticker")
+
+// foreignKeyViolation is the SQLSTATE of a row referencing a missing row.
+const foreignKeyViolation = "23503"
+
+// holdingError maps a failed write of h to ErrUnknownTicker when the ticker
+// isn't a company, and wraps it as action otherwise.
+func holdingError(err error, action string, h *models.PortfolioHolding) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return fmt.Errorf("%w %s", ErrUnknownTicker, h.Ticker)
+	}
+	return fmt.Errorf("%s holding: %w", action, err)
+}
+
+// PortfolioRepository handles database operations for portfolio holdings.
+type PortfolioRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPortfolioRepository creates a new portfolio repository.
+func NewPortfolioRepository(pool *pgxpool.Pool) *PortfolioRepository {
+	return &PortfolioRepository{pool: pool}
+}
+
+const holdingColumns = `
+	id, ticker, shares_owned, COALESCE(cost_basis, 0), COALESCE(target_weight, 0),
+	acquired_date, created_at, updated_at`
+
+func scanHolding(row pgx.Row) (*models.PortfolioHolding, error) {
+	var h models.PortfolioHolding
+	err := row.Scan(
+		&h.ID, &h.Ticker, &h.SharesOwned, &h.CostBasis, &h.TargetWeight,
+		&h.AcquiredDate, &h.CreatedAt, &h.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrHoldingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scanning holding: %w", err)
+	}
+	return &h, nil
+}
+
+// List returns all holdings ordered by ticker.
+func (r *PortfolioRepository) List(ctx context.Context) ([]models.PortfolioHolding, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+holdingColumns+" FROM portfolio ORDER BY ticker, id")
+	if err != nil {
+		return nil, fmt.Errorf("querying portfolio: %w", err)
+	}
+	defer rows.Close()
+
+	var holdings []models.PortfolioHolding
+	for rows.Next() {
+		h, err := scanHolding(rows)
+		if err != nil {
+			return nil, err
+		}
+		holdings = append(holdings, *h)
+	}
+
+	return holdings, rows.Err()
+}
+
+// Get returns a single holding by ID.
+func (r *PortfolioRepository) Get(ctx context.Context, id int) (*models.PortfolioHolding, error) {
+	return scanHolding(r.pool.QueryRow(ctx, "SELECT "+holdingColumns+" FROM portfolio WHERE id = $1", id))
+}
+
+// Create inserts a holding and fills in its ID and timestamps.
+func (r *PortfolioRepository) Create(ctx context.Context, h *models.PortfolioHolding) error {
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO portfolio (ticker, shares_owned, cost_basis, target_weight, acquired_date)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`, h.Ticker, h.SharesOwned, h.CostBasis, h.TargetWeight, h.AcquiredDate,
+	).Scan(&h.ID, &h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		return holdingError(err, "inserting", h)
+	}
+	return nil
+}
+
+// Update overwrites a holding's editable fields.
+func (r *PortfolioRepository) Update(ctx context.Context, h *models.PortfolioHolding) error {
+	err := r.pool.QueryRow(ctx, `
+		UPDATE portfolio SET
+			ticker = $2,
+			shares_owned = $3,
+			cost_basis = $4,
+			target_weight = $5,
+			acquired_date = $6,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`, h.ID, h.Ticker, h.SharesOwned, h.CostBasis, h.TargetWeight, h.AcquiredDate,
+	).Scan(&h.CreatedAt, &h.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrHoldingNotFound
+	}
+	if err != nil {
+		return holdingError(err, "updating", h)
+	}
+	return nil
+}
+
+// Delete removes a holding.
+func (r *PortfolioRepository) Delete(ctx context.Context, id int) error {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM portfolio WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("deleting holding: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrHoldingNotFound
+	}
+	return nil
+}
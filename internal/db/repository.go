@@ -9,11 +9,68 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
 	"github.com/shopspring/decimal"
 )
 
 const dbBatchSize = 1000 // Rows per database batch for resilience
 
+// UpsertStats summarises a batched upsert. Failed database batches don't stop the
+// upsert, so their errors are collected here rather than returned.
+type UpsertStats struct {
+	Rows       int           // Rows written
+	Overflowed int           // Values stored as NULL because they overflow their column
+	FailedRows int           // Rows in database batches that failed
+	Errors     []error       // One per failed database batch
+	Rejected   []RejectedRow // Overflowed values and failed rows, for the dead-letter table
+
+	// Set by the COPY path only, which can tell them apart. Rows is their sum.
+	Inserted  int
+	Updated   int
+	Unchanged int // Key already present with identical values, not rewritten
+}
+
+// RejectedRow is a row that was not stored as fetched: either one of its values
+// overflowed its column and was stored as NULL, or its database batch failed.
+type RejectedRow struct {
+	Table  string // Target table
+	Ticker string
+	Field  string // Overflowing column, empty for failed batches
+	Reason string
+	Index  int // Position of the row in the upserted slice
+	Row    any // The row as fetched, stored as JSON
+}
+
+// merge adds a successful database batch starting at offset.
+func (s *UpsertStats) merge(batch UpsertStats, offset int) {
+	s.Rows += batch.Rows
+	s.Overflowed += batch.Overflowed
+	for _, rej := range batch.Rejected {
+		rej.Index += offset
+		s.Rejected = append(s.Rejected, rej)
+	}
+}
+
+// fail records a failed database batch of n rows starting at offset. The batch
+// runs in an implicit transaction, so none of its rows were written; the caller
+// rejects each of them.
+func (s *UpsertStats) fail(offset, n int, err error) {
+	s.FailedRows += n
+	s.Errors = append(s.Errors, fmt.Errorf("rows %d-%d: %w", offset, offset+n, err))
+}
+
+// reject records a row for the dead-letter table.
+func (s *UpsertStats) reject(table, ticker, field, reason string, index int, row any) {
+	s.Rejected = append(s.Rejected, RejectedRow{
+		Table:  table,
+		Ticker: ticker,
+		Field:  field,
+		Reason: reason,
+		Index:  index,
+		Row:    row,
+	})
+}
+
 // Repository handles database operations for ingested data.
 type Repository struct {
 	pool *pgxpool.Pool
@@ -33,16 +90,29 @@ func (r *Repository) UpsertCompanies(ctx context.Context, tickers []ingest.Ticke
 
 	batch := &pgx.Batch{}
 	for _, t := range tickers {
+		// Delisted companies are kept (inactive) so their history stays available
+		var delistedDate *time.Time
+		if t.IsDelisted {
+			delistedDate = t.LastPrice
+		}
+
 		batch.Queue(`
-			INSERT INTO companies (ticker, name, sector, industry, active, updated_at)
-			VALUES ($1, $2, $3, $4, $5, NOW())
+			INSERT INTO companies (
+				ticker, name, sector, industry, active,
+				is_delisted, first_price_date, delisted_date, updated_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
 			ON CONFLICT (ticker) DO UPDATE SET
 				name = EXCLUDED.name,
 				sector = EXCLUDED.sector,
 				industry = EXCLUDED.industry,
 				active = EXCLUDED.active,
+				is_delisted = EXCLUDED.is_delisted,
+				first_price_date = EXCLUDED.first_price_date,
+				delisted_date = EXCLUDED.delisted_date,
 				updated_at = NOW()
-		`, t.Ticker, t.Name, t.Sector, t.Industry, !t.IsDelisted)
+		`, t.Ticker, t.Name, t.Sector, t.Industry, !t.IsDelisted,
+			t.IsDelisted, t.FirstPrice, delistedDate)
 	}
 
 	br := r.pool.SendBatch(ctx, batch)
@@ -62,13 +132,16 @@ func (r *Repository) UpsertCompanies(ctx context.Context, tickers []ingest.Ticke
 
 // UpsertFinancialMetrics inserts or updates financial metrics from SF1 data.
 // Processes in batches for resilience - a single bad row won't fail the entire import.
-func (r *Repository) UpsertFinancialMetrics(ctx context.Context, rows []ingest.SF1Row) (int, error) {
+func (r *Repository) UpsertFinancialMetrics(ctx context.Context, rows []ingest.SF1Row) (UpsertStats, error) {
+	var stats UpsertStats
 	if len(rows) == 0 {
-		return 0, nil
+		return stats, nil
 	}
 
-	totalCount := 0
-	var lastErr error
+	limits, err := r.columnLimits(ctx, "financial_metrics")
+	if err != nil {
+		return stats, err
+	}
 
 	for i := 0; i < len(rows); i += dbBatchSize {
 		end := i + dbBatchSize
@@ -77,25 +150,31 @@ func (r *Repository) UpsertFinancialMetrics(ctx context.Context, rows []ingest.S
 		}
 		batchRows := rows[i:end]
 
-		count, err := r.upsertFinancialMetricsBatch(ctx, batchRows)
-		totalCount += count
+		batch := UpsertStats{}
+		batch.Rows, err = r.upsertFinancialMetricsBatch(ctx, batchRows, &decimalSanitizer{table: "financial_metrics", limits: limits, stats: &batch})
 		if err != nil {
-			log.Printf("Error in metrics batch %d-%d: %v (inserted %d before error)", i, end, err, count)
-			lastErr = err
-			// Continue with next batch instead of failing entirely
+			log.Printf("Error in metrics batch %d-%d: %v", i, end, err)
+			stats.fail(i, len(batchRows), err)
+			for j, row := range batchRows {
+				stats.reject("financial_metrics", row.Ticker, "", err.Error(), i+j, row)
+			}
+			continue // Continue with next batch instead of failing entirely
 		}
+		stats.merge(batch, i)
 	}
 
-	if lastErr != nil && totalCount == 0 {
-		return 0, lastErr
+	if len(stats.Errors) > 0 && stats.Rows == 0 {
+		return stats, stats.Errors[len(stats.Errors)-1]
 	}
 
-	return totalCount, nil
+	return stats, nil
 }
 
-func (r *Repository) upsertFinancialMetricsBatch(ctx context.Context, rows []ingest.SF1Row) (int, error) {
+func (r *Repository) upsertFinancialMetricsBatch(ctx context.Context, rows []ingest.SF1Row, s *decimalSanitizer) (int, error) {
 	batch := &pgx.Batch{}
-	for _, row := range rows {
+	for i, row := range rows {
+		sanitize := s.row(i, row.Ticker, row)
+
 		reportPeriod := row.DateKey
 		if row.ReportPeriod != nil {
 			reportPeriod = *row.ReportPeriod
@@ -133,18 +212,18 @@ func (r *Repository) upsertFinancialMetricsBatch(ctx context.Context, rows []ing
 				updated_at = NOW()
 		`,
 			row.Ticker, row.Dimension, row.DateKey, reportPeriod,
-			sanitizeDecimal(row.Revenue, "revenue", row.Ticker, 2),
-			sanitizeDecimal(row.NetIncome, "net_income", row.Ticker, 2),
-			sanitizeDecimal(row.EBITDA, "ebitda", row.Ticker, 2),
-			sanitizeDecimal(row.FCF, "fcf", row.Ticker, 2),
-			sanitizeDecimal(row.ROIC, "roic", row.Ticker, 4),
-			sanitizeDecimal(row.PE, "pe_ratio", row.Ticker, 4),
-			sanitizeDecimal(row.EVEBIT, "ev_ebit", row.Ticker, 4),
-			sanitizeDecimal(row.PB, "pb_ratio", row.Ticker, 4),
-			sanitizeDecimal(row.DE, "debt_to_equity", row.Ticker, 4),
-			sanitizeDecimal(row.MarketCap, "market_cap", row.Ticker, 2),
-			sanitizeDecimal(row.EV, "enterprise_value", row.Ticker, 2),
-			sanitizeDecimal(row.Price, "price", row.Ticker, 6),
+			sanitize(row.Revenue, "revenue"),
+			sanitize(row.NetIncome, "net_income"),
+			sanitize(row.EBITDA, "ebitda"),
+			sanitize(row.FCF, "fcf"),
+			sanitize(row.ROIC, "roic"),
+			sanitize(row.PE, "pe_ratio"),
+			sanitize(row.EVEBIT, "ev_ebit"),
+			sanitize(row.PB, "pb_ratio"),
+			sanitize(row.DE, "debt_to_equity"),
+			sanitize(row.MarketCap, "market_cap"),
+			sanitize(row.EV, "enterprise_value"),
+			sanitize(row.Price, "price"),
 			row.LastUpdated,
 		)
 	}
@@ -166,13 +245,16 @@ func (r *Repository) upsertFinancialMetricsBatch(ctx context.Context, rows []ing
 
 // UpsertDailyPrices inserts or updates daily price data.
 // Processes in batches for resilience - a single bad row won't fail the entire import.
-func (r *Repository) UpsertDailyPrices(ctx context.Context, rows []ingest.DailyRow) (int, error) {
+func (r *Repository) UpsertDailyPrices(ctx context.Context, rows []ingest.DailyRow) (UpsertStats, error) {
+	var stats UpsertStats
 	if len(rows) == 0 {
-		return 0, nil
+		return stats, nil
 	}
 
-	totalCount := 0
-	var lastErr error
+	limits, err := r.columnLimits(ctx, "daily_prices")
+	if err != nil {
+		return stats, err
+	}
 
 	for i := 0; i < len(rows); i += dbBatchSize {
 		end := i + dbBatchSize
@@ -181,24 +263,31 @@ func (r *Repository) UpsertDailyPrices(ctx context.Context, rows []ingest.DailyR
 		}
 		batchRows := rows[i:end]
 
-		count, err := r.upsertDailyPricesBatch(ctx, batchRows)
-		totalCount += count
+		batch := UpsertStats{}
+		batch.Rows, err = r.upsertDailyPricesBatch(ctx, batchRows, &decimalSanitizer{table: "daily_prices", limits: limits, stats: &batch})
 		if err != nil {
-			log.Printf("Error in daily batch %d-%d: %v (inserted %d before error)", i, end, err, count)
-			lastErr = err
+			log.Printf("Error in daily batch %d-%d: %v", i, end, err)
+			stats.fail(i, len(batchRows), err)
+			for j, row := range batchRows {
+				stats.reject("daily_prices", row.Ticker, "", err.Error(), i+j, row)
+			}
+			continue // Continue with next batch instead of failing entirely
 		}
+		stats.merge(batch, i)
 	}
 
-	if lastErr != nil && totalCount == 0 {
-		return 0, lastErr
+	if len(stats.Errors) > 0 && stats.Rows == 0 {
+		return stats, stats.Errors[len(stats.Errors)-1]
 	}
 
-	return totalCount, nil
+	return stats, nil
 }
 
-func (r *Repository) upsertDailyPricesBatch(ctx context.Context, rows []ingest.DailyRow) (int, error) {
+func (r *Repository) upsertDailyPricesBatch(ctx context.Context, rows []ingest.DailyRow, s *decimalSanitizer) (int, error) {
 	batch := &pgx.Batch{}
-	for _, row := range rows {
+	for i, row := range rows {
+		sanitize := s.row(i, row.Ticker, row)
+
 		batch.Queue(`
 			INSERT INTO daily_prices (
 				ticker, date, open, high, low, close, volume,
@@ -227,10 +316,10 @@ func (r *Repository) upsertDailyPricesBatch(ctx context.Context, rows []ingest.D
 			decimalPtr(row.Open), decimalPtr(row.High), decimalPtr(row.Low), decimalPtr(row.Close),
 			row.Volume,
 			decimalPtr(row.Dividends), decimalPtr(row.CloseUnadj),
-			sanitizeDecimal(row.MarketCap, "market_cap", row.Ticker, 2),
-			sanitizeDecimal(row.EV, "enterprise_value", row.Ticker, 2),
-			sanitizeDecimal(row.PE, "pe_ratio", row.Ticker, 4),
-			sanitizeDecimal(row.PB, "pb_ratio", row.Ticker, 4),
+			sanitize(row.MarketCap, "market_cap"),
+			sanitize(row.EV, "enterprise_value"),
+			sanitize(row.PE, "pe_ratio"),
+			sanitize(row.PB, "pb_ratio"),
 			row.LastUpdated,
 		)
 	}
@@ -252,7 +341,7 @@ func (r *Repository) upsertDailyPricesBatch(ctx context.Context, rows []ingest.D
 
 // GetLastSharadarUpdate returns the most recent update timestamp for a table.
 // For financial_metrics, returns MAX(last_updated) since we use lastupdated.gte for API filtering.
-// For daily_prices, returns MAX(date) since we use date.gte for API filtering.
+// For daily_prices and equity_prices, returns MAX(date) since we use date.gte for API filtering.
 func (r *Repository) GetLastSharadarUpdate(ctx context.Context, table string) (time.Time, error) {
 	var query string
 	switch table {
@@ -261,6 +350,8 @@ func (r *Repository) GetLastSharadarUpdate(ctx context.Context, table string) (t
 	case "daily_prices":
 		// Use MAX(date) not MAX(last_updated) because Sharadar updates last_updated daily for ALL rows
 		query = "SELECT COALESCE(MAX(date), '1970-01-01'::date)::timestamp FROM daily_prices"
+	case "equity_prices":
+		query = "SELECT COALESCE(MAX(date), '1970-01-01'::date)::timestamp FROM equity_prices"
 	default:
 		return time.Time{}, fmt.Errorf("unknown table: %s", table)
 	}
@@ -274,6 +365,125 @@ func (r *Repository) GetLastSharadarUpdate(ctx context.Context, table string) (t
 	return lastUpdate, nil
 }
 
+// GetWatermarks returns the incremental fetch watermark of every ticker stored
+// in a table: MAX(last_updated) per ticker and dimension for financial_metrics
+// (matching the lastupdated.gte filter), and MAX(date) per ticker for
+// daily_prices, equity_prices and fund_prices (matching date.gte). Tickers without rows
+// are absent, so they are fetched from the beginning.
+func (r *Repository) GetWatermarks(ctx context.Context, table, dimension string) (map[string]time.Time, error) {
+	var rows pgx.Rows
+	var err error
+	switch table {
+	case "financial_metrics":
+		rows, err = r.pool.Query(ctx, `
+			SELECT ticker, MAX(last_updated)
+			FROM financial_metrics
+			WHERE dimension = $1 AND last_updated IS NOT NULL
+			GROUP BY ticker
+		`, dimension)
+	case "daily_prices":
+		rows, err = r.pool.Query(ctx, "SELECT ticker, MAX(date)::timestamp FROM daily_prices GROUP BY ticker")
+	case "equity_prices":
+		rows, err = r.pool.Query(ctx, "SELECT ticker, MAX(date)::timestamp FROM equity_prices GROUP BY ticker")
+	case "fund_prices":
+		rows, err = r.pool.Query(ctx, "SELECT ticker, MAX(date)::timestamp FROM fund_prices GROUP BY ticker")
+	default:
+		return nil, fmt.Errorf("unknown table: %s", table)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying %s watermarks: %w", table, err)
+	}
+	defer rows.Close()
+
+	marks := make(map[string]time.Time)
+	for rows.Next() {
+		var ticker string
+		var mark time.Time
+		if err := rows.Scan(&ticker, &mark); err != nil {
+			return nil, fmt.Errorf("scanning watermark: %w", err)
+		}
+		marks[ticker] = mark
+	}
+
+	return marks, rows.Err()
+}
+
+// GetFundamentalsAsOf returns, for each ticker, the latest financial_metrics row of the
+// given dimension whose date_key (SEC filing date) is on or before asOf.
+// This is the data a strategy could actually have seen on that date; selecting on
+// report_period or on the newest row would leak filings that weren't public yet.
+func (r *Repository) GetFundamentalsAsOf(ctx context.Context, asOf time.Time, dimension string) ([]models.FinancialMetric, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT ON (ticker)
+			id, ticker, dimension, date_key, report_period,
+			revenue, net_income, ebitda, fcf,
+			roic, pe_ratio, ev_ebit, pb_ratio, debt_to_equity,
+			market_cap, enterprise_value, price,
+			last_updated, created_at, updated_at
+		FROM financial_metrics
+		WHERE dimension = $1 AND date_key <= $2
+		ORDER BY ticker, date_key DESC
+	`, dimension, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("querying fundamentals as of %s: %w", asOf.Format("2006-01-02"), err)
+	}
+	defer rows.Close()
+
+	var metrics []models.FinancialMetric
+	for rows.Next() {
+		var m models.FinancialMetric
+		if err := rows.Scan(
+			&m.ID, &m.Ticker, &m.Dimension, &m.DateKey, &m.ReportPeriod,
+			&m.Revenue, &m.NetIncome, &m.EBITDA, &m.FCF,
+			&m.ROIC, &m.PERatio, &m.EVEBIT, &m.PBRatio, &m.DebtToEquity,
+			&m.MarketCap, &m.EnterpriseValue, &m.Price,
+			&m.LastUpdated, &m.CreatedAt, &m.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scanning financial metric: %w", err)
+		}
+		metrics = append(metrics, m)
+	}
+
+	return metrics, rows.Err()
+}
+
+// GetCompanies returns all companies keyed by ticker.
+func (r *Repository) GetCompanies(ctx context.Context) (map[string]models.Company, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT ticker, COALESCE(name, ''), COALESCE(sector, ''), COALESCE(industry, ''),
+			COALESCE(active, true), is_delisted, first_price_date, delisted_date,
+			created_at, updated_at
+		FROM companies
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying companies: %w", err)
+	}
+	defer rows.Close()
+
+	companies := make(map[string]models.Company)
+	for rows.Next() {
+		var c models.Company
+		if err := rows.Scan(
+			&c.Ticker, &c.Name, &c.Sector, &c.Industry,
+			&c.Active, &c.IsDelisted, &c.FirstPriceDate, &c.DelistedDate,
+			&c.CreatedAt, &c.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scanning company: %w", err)
+		}
+		companies[c.Ticker] = c
+	}
+
+	return companies, rows.Err()
+}
+
+// GetSetting returns the value of a row in the settings table.
+// Returns pgx.ErrNoRows if the key does not exist.
+func (r *Repository) GetSetting(ctx context.Context, key string) (string, error) {
+	var value string
+	err := r.pool.QueryRow(ctx, "SELECT value FROM settings WHERE key = $1", key).Scan(&value)
+	return value, err
+}
+
 // GetCompanyCount returns the number of companies in the database.
 func (r *Repository) GetCompanyCount(ctx context.Context) (int, error) {
 	var count int
@@ -288,12 +498,36 @@ func (r *Repository) CompanyExists(ctx context.Context, ticker string) (bool, er
 	return exists, err
 }
 
-// Limits for DECIMAL columns (digits before decimal point)
-var (
-	maxDecimal18_2 = decimal.NewFromInt(1).Shift(16) // 10^16 for DECIMAL(18,2)
-	maxDecimal18_4 = decimal.NewFromInt(1).Shift(14) // 10^14 for DECIMAL(18,4)
-	maxDecimal18_6 = decimal.NewFromInt(1).Shift(12) // 10^12 for DECIMAL(18,6)
-)
+// columnLimits returns the exclusive upper bound of each DECIMAL column in a table
+// (10^(precision-scale), e.g. 10^16 for DECIMAL(18,2)). Limits are read from the
+// schema on every upsert so a widened column takes effect without a code change,
+// which is what lets dead-lettered values be retried after a migration.
+func (r *Repository) columnLimits(ctx context.Context, table string) (map[string]decimal.Decimal, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT column_name, numeric_precision, numeric_scale
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()
+		  AND table_name = $1
+		  AND data_type = 'numeric'
+		  AND numeric_precision IS NOT NULL
+	`, table)
+	if err != nil {
+		return nil, fmt.Errorf("querying %s column limits: %w", table, err)
+	}
+	defer rows.Close()
+
+	limits := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var column string
+		var precision, scale int32
+		if err := rows.Scan(&column, &precision, &scale); err != nil {
+			return nil, fmt.Errorf("scanning %s column limits: %w", table, err)
+		}
+		limits[column] = decimal.NewFromInt(1).Shift(precision - scale)
+	}
+
+	return limits, rows.Err()
+}
 
 // decimalPtr converts a *decimal.Decimal to interface{} for database insertion.
 func decimalPtr(d *decimal.Decimal) interface{} {
@@ -303,35 +537,68 @@ func decimalPtr(d *decimal.Decimal) interface{} {
 	return *d
 }
 
-// sanitizeDecimal checks if value fits in column, logs and returns nil if overflow
-func sanitizeDecimal(d *decimal.Decimal, field, ticker string, scale int) interface{} {
+// decimalSanitizer nulls values that overflow their column during a database
+// batch and records them as rejected.
+type decimalSanitizer struct {
+	table  string
+	limits map[string]decimal.Decimal // From columnLimits
+	stats  *UpsertStats
+}
+
+// row returns the sanitizeDecimal function for one row of the batch.
+func (s *decimalSanitizer) row(index int, ticker string, row any) func(d *decimal.Decimal, field string) interface{} {
+	return func(d *decimal.Decimal, field string) interface{} {
+		return s.sanitizeDecimal(d, field, index, ticker, row)
+	}
+}
+
+// sanitizeDecimal checks if value fits in column, logs and rejects it and returns nil if overflow
+func (s *decimalSanitizer) sanitizeDecimal(d *decimal.Decimal, field string, index int, ticker string, row any) interface{} {
 	if d == nil {
 		return nil
 	}
 
-	var limit decimal.Decimal
-	switch scale {
-	case 2:
-		limit = maxDecimal18_2
-	case 4:
-		limit = maxDecimal18_4
-	case 6:
-		limit = maxDecimal18_6
-	default:
-		limit = maxDecimal18_4
+	limit, ok := s.limits[field]
+	if !ok {
+		return *d // Unconstrained NUMERIC
 	}
 
-	abs := d.Abs()
-	if abs.GreaterThan(limit) {
-		log.Printf("OVERFLOW: %s.%s = %s (exceeds DECIMAL(18,%d) limit)", ticker, field, d.String(), scale)
+	if d.Abs().GreaterThanOrEqual(limit) {
+		reason := fmt.Sprintf("%s = %s exceeds column limit %s", field, d.String(), limit.String())
+		log.Printf("OVERFLOW: %s.%s = %s (exceeds %s.%s limit)", ticker, field, d.String(), s.table, field)
+		s.stats.Overflowed++
+		s.stats.reject(s.table, ticker, field, reason, index, row)
 		return nil // Skip this value instead of failing
 	}
 	return *d
 }
 
-// GetAllTickers returns all tickers from the companies table.
+// GetAllTickers returns all tickers from the companies table, including delisted
+// companies so their fundamentals and prices keep being ingested for history.
 func (r *Repository) GetAllTickers(ctx context.Context) ([]string, error) {
-	rows, err := r.pool.Query(ctx, "SELECT ticker FROM companies WHERE active = true ORDER BY ticker")
+	return r.queryTickers(ctx, "SELECT ticker FROM companies ORDER BY ticker")
+}
+
+// GetActiveTickers returns the tickers of companies eligible for today's screens.
+func (r *Repository) GetActiveTickers(ctx context.Context) ([]string, error) {
+	return r.queryTickers(ctx, "SELECT ticker FROM companies WHERE active = true ORDER BY ticker")
+}
+
+// GetUniverseAt returns the tickers that were listed on the given date: priced by
+// then and not yet delisted. Unlike `active`, this includes companies that later
+// went bankrupt or were acquired, which avoids survivorship bias in backtests.
+func (r *Repository) GetUniverseAt(ctx context.Context, date time.Time) ([]string, error) {
+	return r.queryTickers(ctx, `
+		SELECT ticker FROM companies
+		WHERE (first_price_date IS NULL OR first_price_date <= $1)
+			AND (delisted_date IS NULL OR delisted_date >= $1)
+		ORDER BY ticker
+	`, date)
+}
+
+// queryTickers runs a query returning a single ticker column.
+func (r *Repository) queryTickers(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -363,6 +630,13 @@ func (r *Repository) GetDailyPriceCount(ctx context.Context) (int, error) {
 	return count, err
 }
 
+// GetEquityPriceCount returns the number of SEP equity prices in the database.
+func (r *Repository) GetEquityPriceCount(ctx context.Context) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM equity_prices").Scan(&count)
+	return count, err
+}
+
 // GetBenchmarkTickers returns all benchmark tickers.
 func (r *Repository) GetBenchmarkTickers(ctx context.Context) ([]string, error) {
 	rows, err := r.pool.Query(ctx, "SELECT ticker FROM benchmarks ORDER BY ticker")
@@ -383,70 +657,107 @@ func (r *Repository) GetBenchmarkTickers(ctx context.Context) ([]string, error)
 	return tickers, rows.Err()
 }
 
-// UpsertBenchmarkPrices inserts or updates benchmark price data.
-func (r *Repository) UpsertBenchmarkPrices(ctx context.Context, rows []ingest.DailyRow) (int, error) {
-	if len(rows) == 0 {
-		return 0, nil
+// GetLastBenchmarkUpdate returns the most recent date of the benchmarks' fund prices.
+// Uses MAX(date) not MAX(last_updated) because Sharadar updates last_updated daily for ALL rows.
+func (r *Repository) GetLastBenchmarkUpdate(ctx context.Context) (time.Time, error) {
+	var lastUpdate time.Time
+	err := r.pool.QueryRow(ctx, `
+		SELECT COALESCE(MAX(date), '1970-01-01'::date)::timestamp
+		FROM fund_prices
+		WHERE ticker IN (SELECT ticker FROM benchmarks)
+	`).Scan(&lastUpdate)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("querying last benchmark update: %w", err)
 	}
+	return lastUpdate, nil
+}
 
-	batch := &pgx.Batch{}
-	for _, row := range rows {
-		batch.Queue(`
-			INSERT INTO benchmark_prices (
-				ticker, date, open, high, low, close, volume,
-				dividends, close_unadj, last_updated
-			) VALUES (
-				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
-			)
-			ON CONFLICT (ticker, date) DO UPDATE SET
-				open = EXCLUDED.open,
-				high = EXCLUDED.high,
-				low = EXCLUDED.low,
-				close = EXCLUDED.close,
-				volume = EXCLUDED.volume,
-				dividends = EXCLUDED.dividends,
-				close_unadj = EXCLUDED.close_unadj,
-				last_updated = EXCLUDED.last_updated
-		`,
-			row.Ticker, row.Date,
-			decimalPtr(row.Open), decimalPtr(row.High), decimalPtr(row.Low), decimalPtr(row.Close),
-			row.Volume,
-			decimalPtr(row.Dividends), decimalPtr(row.CloseUnadj),
-			row.LastUpdated,
-		)
-	}
+// GetFundPriceCount returns the number of SFP fund prices in the database,
+// benchmarks included.
+func (r *Repository) GetFundPriceCount(ctx context.Context) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM fund_prices").Scan(&count)
+	return count, err
+}
 
-	br := r.pool.SendBatch(ctx, batch)
-	defer br.Close()
+// GetBenchmarks returns all configured benchmarks.
+func (r *Repository) GetBenchmarks(ctx context.Context) ([]models.Benchmark, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT ticker, name, COALESCE(description, ''), created_at, updated_at
+		FROM benchmarks
+		ORDER BY ticker
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying benchmarks: %w", err)
+	}
+	defer rows.Close()
 
-	count := 0
-	for range rows {
-		_, err := br.Exec()
-		if err != nil {
-			return count, fmt.Errorf("upserting benchmark price: %w", err)
+	var benchmarks []models.Benchmark
+	for rows.Next() {
+		var b models.Benchmark
+		if err := rows.Scan(&b.Ticker, &b.Name, &b.Description, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scanning benchmark: %w", err)
 		}
-		count++
+		benchmarks = append(benchmarks, b)
 	}
 
-	return count, nil
+	return benchmarks, rows.Err()
 }
 
-// GetLastBenchmarkUpdate returns the most recent date for benchmark prices.
-// Uses MAX(date) not MAX(last_updated) because Sharadar updates last_updated daily for ALL rows.
-func (r *Repository) GetLastBenchmarkUpdate(ctx context.Context) (time.Time, error) {
-	var lastUpdate time.Time
-	err := r.pool.QueryRow(ctx,
-		"SELECT COALESCE(MAX(date), '1970-01-01'::date)::timestamp FROM benchmark_prices",
-	).Scan(&lastUpdate)
+// GetBenchmarkPrices returns the SFP closes of a benchmark between start and end
+// (inclusive). Only Ticker, Date, Close and CloseAdj are populated; CloseAdj falls
+// back to Close where the adjusted close is missing.
+func (r *Repository) GetBenchmarkPrices(ctx context.Context, ticker string, start, end time.Time) ([]models.BenchmarkPrice, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT ticker, date, close, COALESCE(close_adj, close)
+		FROM fund_prices
+		WHERE ticker = $1 AND date >= $2 AND date <= $3 AND close IS NOT NULL
+		ORDER BY date
+	`, ticker, start, end)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("querying last benchmark update: %w", err)
+		return nil, fmt.Errorf("querying benchmark prices: %w", err)
 	}
-	return lastUpdate, nil
+	defer rows.Close()
+
+	var prices []models.BenchmarkPrice
+	for rows.Next() {
+		var p models.BenchmarkPrice
+		if err := rows.Scan(&p.Ticker, &p.Date, &p.Close, &p.CloseAdj); err != nil {
+			return nil, fmt.Errorf("scanning benchmark price: %w", err)
+		}
+		prices = append(prices, p)
+	}
+
+	return prices, rows.Err()
 }
 
-// GetBenchmarkPriceCount returns the number of benchmark prices in the database.
-func (r *Repository) GetBenchmarkPriceCount(ctx context.Context) (int, error) {
-	var count int
-	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM benchmark_prices").Scan(&count)
-	return count, err
-}
\ No newline at end of file
+// GetLatestCloses returns the most recent SEP close for each of the given tickers.
+// Tickers without any price are omitted from the result.
+func (r *Repository) GetLatestCloses(ctx context.Context, tickers []string) (map[string]decimal.Decimal, error) {
+	closes := make(map[string]decimal.Decimal, len(tickers))
+	if len(tickers) == 0 {
+		return closes, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT ON (ticker) ticker, close
+		FROM equity_prices
+		WHERE ticker = ANY($1) AND close IS NOT NULL
+		ORDER BY ticker, date DESC
+	`, tickers)
+	if err != nil {
+		return nil, fmt.Errorf("querying latest closes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ticker string
+		var close decimal.Decimal
+		if err := rows.Scan(&ticker, &close); err != nil {
+			return nil, fmt.Errorf("scanning close: %w", err)
+		}
+		closes[ticker] = close
+	}
+
+	return closes, rows.Err()
+}
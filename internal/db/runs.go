@@ -0,0 +1,251 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+)
+
+// ErrRunNotFound is returned when an ingest run does not exist.
+var ErrRunNotFound = errors.New("run not found")
+
+// maxRunErrors caps the error and warning messages kept per run; error_count
+// keeps the total of errors.
+const maxRunErrors = 50
+
+// RunProgress is the running totals of an ingest run.
+type RunProgress struct {
+	Batches          int
+	Rows             int64
+	ValuesOverflowed int
+	RowsFailed       int
+
+	// Split of Rows from the COPY path, see UpsertStats
+	RowsInserted  int64
+	RowsUpdated   int64
+	RowsUnchanged int64
+}
+
+// RunRepository handles database operations for the ingest run history.
+type RunRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRunRepository creates a new run repository.
+func NewRunRepository(pool *pgxpool.Pool) *RunRepository {
+	return &RunRepository{pool: pool}
+}
+
+// CreateRun inserts a running run and returns its ID.
+func (r *RunRepository) CreateRun(ctx context.Context, kind, endpoint string, params any) (int64, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return 0, fmt.Errorf("encoding run params: %w", err)
+	}
+
+	var id int64
+	err = r.pool.QueryRow(ctx, `
+		INSERT INTO ingest_runs (kind, endpoint, params, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, kind, endpoint, raw, models.RunRunning).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("inserting run: %w", err)
+	}
+	return id, nil
+}
+
+// UpdateRunProgress records the totals completed so far. Totals only grow, so
+// concurrent updates landing out of order are harmless.
+func (r *RunRepository) UpdateRunProgress(ctx context.Context, id int64, p RunProgress) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE ingest_runs SET
+			batches_done = GREATEST(batches_done, $2),
+			rows_upserted = GREATEST(rows_upserted, $3),
+			values_overflowed = GREATEST(values_overflowed, $4),
+			rows_failed = GREATEST(rows_failed, $5),
+			rows_inserted = GREATEST(rows_inserted, $6),
+			rows_updated = GREATEST(rows_updated, $7),
+			rows_unchanged = GREATEST(rows_unchanged, $8)
+		WHERE id = $1
+	`, id, p.Batches, p.Rows, p.ValuesOverflowed, p.RowsFailed, p.RowsInserted, p.RowsUpdated, p.RowsUnchanged)
+	if err != nil {
+		return fmt.Errorf("updating run progress: %w", err)
+	}
+	return nil
+}
+
+// SetRunWatermark records the earliest incremental watermark a run fetched from
+// and how many watermark groups it used. A nil since means all history.
+func (r *RunRepository) SetRunWatermark(ctx context.Context, id int64, since *time.Time, groups int) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE ingest_runs SET since = $2, watermark_groups = $3 WHERE id = $1
+	`, id, since, groups)
+	if err != nil {
+		return fmt.Errorf("updating run watermark: %w", err)
+	}
+	return nil
+}
+
+// AddRunError records an error against a run without stopping it.
+func (r *RunRepository) AddRunError(ctx context.Context, id int64, msg string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE ingest_runs SET
+			error_count = error_count + 1,
+			errors = CASE WHEN cardinality(errors) < $3 THEN array_append(errors, $2) ELSE errors END
+		WHERE id = $1
+	`, id, msg, maxRunErrors)
+	if err != nil {
+		return fmt.Errorf("recording run error: %w", err)
+	}
+	return nil
+}
+
+// AddRunWarning records a warning against a run. A warning already recorded
+// isn't added again, since every batch of a fetch can report the same one.
+func (r *RunRepository) AddRunWarning(ctx context.Context, id int64, msg string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE ingest_runs SET warnings = array_append(warnings, $2)
+		WHERE id = $1 AND NOT ($2 = ANY(warnings)) AND cardinality(warnings) < $3
+	`, id, msg, maxRunErrors)
+	if err != nil {
+		return fmt.Errorf("recording run warning: %w", err)
+	}
+	return nil
+}
+
+// FinishRun sets a run's final status, message and totals.
+func (r *RunRepository) FinishRun(ctx context.Context, id int64, status, message string, p RunProgress) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE ingest_runs SET
+			status = $2,
+			message = $3,
+			batches_done = $4,
+			rows_upserted = $5,
+			values_overflowed = $6,
+			rows_failed = $7,
+			rows_inserted = $8,
+			rows_updated = $9,
+			rows_unchanged = $10,
+			finished_at = NOW()
+		WHERE id = $1
+	`, id, status, message, p.Batches, p.Rows, p.ValuesOverflowed, p.RowsFailed, p.RowsInserted, p.RowsUpdated, p.RowsUnchanged)
+	if err != nil {
+		return fmt.Errorf("finishing run: %w", err)
+	}
+	return nil
+}
+
+// FailRunningRuns marks every run still recorded as running as failed. Must only
+// be called holding the ingestion advisory lock, when no run can be executing.
+func (r *RunRepository) FailRunningRuns(ctx context.Context, message string) (int, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE ingest_runs SET status = $1, message = $2, finished_at = NOW()
+		WHERE status = $3
+	`, models.RunFailed, message, models.RunRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failing running runs: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+const runColumns = `
+	id, kind, endpoint, params, status, COALESCE(message, ''), since, watermark_groups,
+	batches_done, rows_upserted, values_overflowed, rows_failed,
+	rows_inserted, rows_updated, rows_unchanged,
+	error_count, errors, warnings, started_at, finished_at`
+
+func scanRun(row pgx.Row) (*models.IngestRun, error) {
+	var run models.IngestRun
+	err := row.Scan(
+		&run.ID, &run.Kind, &run.Endpoint, &run.Params, &run.Status, &run.Message, &run.Since, &run.WatermarkGroups,
+		&run.BatchesDone, &run.RowsUpserted, &run.ValuesOverflowed, &run.RowsFailed,
+		&run.RowsInserted, &run.RowsUpdated, &run.RowsUnchanged,
+		&run.ErrorCount, &run.Errors, &run.Warnings, &run.StartedAt, &run.FinishedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scanning run: %w", err)
+	}
+
+	end := time.Now()
+	if run.FinishedAt != nil {
+		end = *run.FinishedAt
+	}
+	run.Elapsed = end.Sub(run.StartedAt).Round(time.Millisecond).String()
+
+	return &run, nil
+}
+
+// GetRun returns a run by ID.
+func (r *RunRepository) GetRun(ctx context.Context, id int64) (*models.IngestRun, error) {
+	return scanRun(r.pool.QueryRow(ctx, "SELECT "+runColumns+" FROM ingest_runs WHERE id = $1", id))
+}
+
+// ListRuns returns the most recent runs, newest first.
+func (r *RunRepository) ListRuns(ctx context.Context, limit int) ([]models.IngestRun, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+runColumns+" FROM ingest_runs ORDER BY started_at DESC, id DESC LIMIT $1", limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []models.IngestRun
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+
+	return runs, rows.Err()
+}
+
+// RanSince reports whether a run from endpoint started at or after t.
+func (r *RunRepository) RanSince(ctx context.Context, endpoint string, t time.Time) (bool, error) {
+	var ran bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM ingest_runs WHERE endpoint = $1 AND started_at >= $2)
+	`, endpoint, t).Scan(&ran)
+	if err != nil {
+		return false, fmt.Errorf("checking runs since %s: %w", t.Format(time.RFC3339), err)
+	}
+	return ran, nil
+}
+
+// TryAdvisoryLock takes a session-level Postgres advisory lock on a dedicated
+// connection without waiting. If ok is true, unlock must be called to release
+// both the lock and the connection.
+func (r *RunRepository) TryAdvisoryLock(ctx context.Context, key int64) (unlock func(), ok bool, err error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("acquiring connection: %w", err)
+	}
+
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("taking advisory lock: %w", err)
+	}
+	if !ok {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock = func() {
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			log.Printf("Error releasing advisory lock %d: %v", key, err)
+		}
+		conn.Release()
+	}
+	return unlock, true, nil
+}
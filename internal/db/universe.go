@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+)
+
+// UpsertSP500Membership stores S&P 500 membership events from SHARADAR/SP500.
+// The "current" rows are replaced wholesale since their date moves with every refresh.
+func (r *Repository) UpsertSP500Membership(ctx context.Context, rows []ingest.SP500Row) (int, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	hasCurrent := false
+	for _, row := range rows {
+		if row.Action == "current" {
+			hasCurrent = true
+			break
+		}
+	}
+	if hasCurrent {
+		if _, err := tx.Exec(ctx, "DELETE FROM sp500_membership WHERE action = 'current'"); err != nil {
+			return 0, fmt.Errorf("clearing current constituents: %w", err)
+		}
+	}
+
+	batch := &pgx.Batch{}
+	for _, row := range rows {
+		batch.Queue(`
+			INSERT INTO sp500_membership (date, action, ticker, name, contra_ticker, contra_name)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+			ON CONFLICT (date, action, ticker) DO UPDATE SET
+				name = EXCLUDED.name,
+				contra_ticker = EXCLUDED.contra_ticker,
+				contra_name = EXCLUDED.contra_name
+		`, row.Date, row.Action, row.Ticker, row.Name, row.Conticker, row.Conname)
+	}
+
+	br := tx.SendBatch(ctx, batch)
+	count := 0
+	for range rows {
+		if _, err := br.Exec(); err != nil {
+			br.Close()
+			return count, fmt.Errorf("upserting SP500 membership: %w", err)
+		}
+		count++
+	}
+	if err := br.Close(); err != nil {
+		return count, fmt.Errorf("closing batch: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("committing SP500 membership: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetSP500ConstituentsAt rebuilds the S&P 500 constituents on the given date.
+// Works backwards from today's constituents: the first add/remove event after the
+// date decides membership ("removed" later means it was a member, "added" later
+// means it wasn't); tickers with no later event keep their current status.
+func (r *Repository) GetSP500ConstituentsAt(ctx context.Context, date time.Time) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH next_event AS (
+			SELECT DISTINCT ON (ticker) ticker, action
+			FROM sp500_membership
+			WHERE action IN ('added', 'removed') AND date > $1
+			ORDER BY ticker, date ASC
+		)
+		SELECT ticker FROM next_event WHERE action = 'removed'
+		UNION
+		SELECT c.ticker FROM sp500_membership c
+		WHERE c.action = 'current'
+			AND NOT EXISTS (SELECT 1 FROM next_event n WHERE n.ticker = c.ticker)
+		ORDER BY ticker
+	`, date)
+	if err != nil {
+		return nil, fmt.Errorf("querying SP500 constituents: %w", err)
+	}
+	defer rows.Close()
+
+	var tickers []string
+	for rows.Next() {
+		var ticker string
+		if err := rows.Scan(&ticker); err != nil {
+			return nil, err
+		}
+		tickers = append(tickers, ticker)
+	}
+
+	return tickers, rows.Err()
+}
+
+// GetSP500EventCount returns the number of stored S&P 500 membership rows.
+func (r *Repository) GetSP500EventCount(ctx context.Context) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM sp500_membership").Scan(&count)
+	return count, err
+}
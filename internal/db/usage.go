@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+)
+
+// UsageRepository stores daily API usage. It implements ingest.UsageStore.
+type UsageRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewUsageRepository creates a new usage repository.
+func NewUsageRepository(pool *pgxpool.Pool) *UsageRepository {
+	return &UsageRepository{pool: pool}
+}
+
+// GetUsage returns the usage recorded for a day, zero if there is none.
+func (r *UsageRepository) GetUsage(ctx context.Context, day time.Time) (ingest.Usage, error) {
+	usage := ingest.Usage{Day: day}
+	err := r.pool.QueryRow(ctx, `
+		SELECT calls, rows_returned, throttled FROM api_usage WHERE day = $1
+	`, day).Scan(&usage.Calls, &usage.Rows, &usage.Throttled)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return usage, fmt.Errorf("querying API usage: %w", err)
+	}
+	return usage, nil
+}
+
+// AddUsage adds to a day's usage.
+func (r *UsageRepository) AddUsage(ctx context.Context, day time.Time, calls, rows, throttled int64) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO api_usage (day, calls, rows_returned, throttled)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (day) DO UPDATE SET
+			calls = api_usage.calls + EXCLUDED.calls,
+			rows_returned = api_usage.rows_returned + EXCLUDED.rows_returned,
+			throttled = api_usage.throttled + EXCLUDED.throttled,
+			updated_at = NOW()
+	`, day, calls, rows, throttled)
+	if err != nil {
+		return fmt.Errorf("adding API usage: %w", err)
+	}
+	return nil
+}
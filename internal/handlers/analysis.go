@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/mauv0809/crispy-broccoli/internal/analysis"
+	"github.com/mauv0809/crispy-broccoli/internal/views"
+)
+
+// AnalysisHandler runs screening strategies for the dashboard.
+type AnalysisHandler struct {
+	strategies *analysis.Registry
+	pool       *pgxpool.Pool
+}
+
+// NewAnalysisHandler creates a new analysis handler.
+func NewAnalysisHandler(strategies *analysis.Registry, pool *pgxpool.Pool) *AnalysisHandler {
+	return &AnalysisHandler{
+		strategies: strategies,
+		pool:       pool,
+	}
+}
+
+// Analyze handles POST /analyze
+// @Summary Run a screening strategy
+// @Description Runs the selected strategy and returns the proposed portfolio as an HTML partial
+// @Tags analysis
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param strategy formData string true "Strategy name"
+// @Success 200 {string} string "HTML partial"
+// @Router /analyze [post]
+func (h *AnalysisHandler) Analyze(c echo.Context) error {
+	ctx := c.Request().Context()
+	start := time.Now()
+
+	// Errors are rendered with 200 since HTMX only swaps successful responses
+	name := c.FormValue("strategy")
+	strategy, ok := h.strategies.Get(name)
+	if !ok {
+		return Render(c, http.StatusOK, views.AnalysisError(fmt.Sprintf("Unknown strategy %q", name)))
+	}
+
+	log.Printf("Running strategy %s...", name)
+
+	recs, err := strategy.RunScreen(ctx, h.pool)
+	if err != nil {
+		log.Printf("Error running strategy %s: %v", name, err)
+		return Render(c, http.StatusOK, views.AnalysisError(fmt.Sprintf("Failed to run %s: %v", name, err)))
+	}
+
+	log.Printf("Strategy %s complete: %d picks in %v", name, len(recs), time.Since(start))
+
+	return Render(c, http.StatusOK, views.AnalysisResults(name, recs))
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/mauv0809/crispy-broccoli/internal/analysis"
+	"github.com/mauv0809/crispy-broccoli/internal/views"
+	"github.com/shopspring/decimal"
+)
+
+// BacktestHandler runs strategies against stored history.
+type BacktestHandler struct {
+	strategies *analysis.Registry
+	pool       *pgxpool.Pool
+}
+
+// NewBacktestHandler creates a new backtest handler.
+func NewBacktestHandler(strategies *analysis.Registry, pool *pgxpool.Pool) *BacktestHandler {
+	return &BacktestHandler{
+		strategies: strategies,
+		pool:       pool,
+	}
+}
+
+// Page handles GET /backtest
+func (h *BacktestHandler) Page(c echo.Context) error {
+	return Render(c, http.StatusOK, views.Backtest(h.strategies.Names()))
+}
+
+// Run handles POST /backtest
+// @Summary Run a backtest
+// @Description Replays a strategy with quarterly rebalancing over stored prices and returns the results as an HTML partial
+// @Tags analysis
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param strategy formData string true "Strategy name"
+// @Param start formData string true "Start date (YYYY-MM-DD)"
+// @Param end formData string true "End date (YYYY-MM-DD)"
+// @Param capital formData number false "Initial capital" default(100000)
+// @Success 200 {string} string "HTML partial"
+// @Router /backtest [post]
+func (h *BacktestHandler) Run(c echo.Context) error {
+	ctx := c.Request().Context()
+	start := time.Now()
+
+	// Errors are rendered with 200 since HTMX only swaps successful responses
+	name := c.FormValue("strategy")
+	strategy, ok := h.strategies.Get(name)
+	if !ok {
+		return Render(c, http.StatusOK, views.AnalysisError(fmt.Sprintf("Unknown strategy %q", name)))
+	}
+
+	startDate, err := time.Parse("2006-01-02", c.FormValue("start"))
+	if err != nil {
+		return Render(c, http.StatusOK, views.AnalysisError("Invalid start date"))
+	}
+	endDate, err := time.Parse("2006-01-02", c.FormValue("end"))
+	if err != nil {
+		return Render(c, http.StatusOK, views.AnalysisError("Invalid end date"))
+	}
+
+	capital := decimal.NewFromInt(100_000)
+	if capitalParam := c.FormValue("capital"); capitalParam != "" {
+		capital, err = decimal.NewFromString(capitalParam)
+		if err != nil || !capital.IsPositive() {
+			return Render(c, http.StatusOK, views.AnalysisError("Invalid initial capital"))
+		}
+	}
+
+	log.Printf("Running backtest for %s (%s to %s)...", name, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	engine := analysis.NewEngine(h.pool, capital)
+	result, err := engine.Run(ctx, strategy, startDate, endDate)
+	if err != nil {
+		log.Printf("Error running backtest for %s: %v", name, err)
+		return Render(c, http.StatusOK, views.AnalysisError(fmt.Sprintf("Backtest failed: %v", err)))
+	}
+
+	log.Printf("Backtest complete: %s returned %.2f%% with %d trades in %v", name, result.TotalReturn, len(result.Trades), time.Since(start))
+
+	// A missing comparison shouldn't hide the backtest itself
+	report, err := analysis.CompareBenchmarks(ctx, h.pool, name, result.Equity)
+	if err != nil {
+		log.Printf("Error comparing backtest to benchmarks: %v", err)
+	}
+
+	return Render(c, http.StatusOK, views.BacktestResults(result, report))
+}
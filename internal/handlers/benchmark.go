@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/mauv0809/crispy-broccoli/internal/analysis"
+)
+
+// BenchmarkHandler compares portfolios against the configured benchmarks.
+type BenchmarkHandler struct {
+	pool *pgxpool.Pool
+}
+
+// NewBenchmarkHandler creates a new benchmark handler.
+func NewBenchmarkHandler(pool *pgxpool.Pool) *BenchmarkHandler {
+	return &BenchmarkHandler{pool: pool}
+}
+
+// ErrorResponse is the JSON body for failed API requests.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// ComparePortfolio handles GET /api/portfolio/benchmark
+// @Summary Compare the live portfolio to benchmarks
+// @Description Values the current holdings at daily closes over the window and compares total return, CAGR, max drawdown, Sharpe, alpha, beta, tracking error and information ratio against each benchmark
+// @Tags portfolio
+// @Produce json
+// @Param start query string false "Start date (YYYY-MM-DD, default: one year ago)"
+// @Param end query string false "End date (YYYY-MM-DD, default: today)"
+// @Success 200 {object} analysis.ComparisonReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/portfolio/benchmark [get]
+func (h *BenchmarkHandler) ComparePortfolio(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	if endParam := c.QueryParam("end"); endParam != "" {
+		var err error
+		if end, err = time.Parse("2006-01-02", endParam); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid end date"})
+		}
+	}
+
+	start := end.AddDate(-1, 0, 0)
+	if startParam := c.QueryParam("start"); startParam != "" {
+		var err error
+		if start, err = time.Parse("2006-01-02", startParam); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid start date"})
+		}
+	}
+
+	if !end.After(start) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "end date must be after start date"})
+	}
+
+	series, err := analysis.HoldingsValueSeries(ctx, h.pool, start, end)
+	if errors.Is(err, analysis.ErrNoHoldings) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "portfolio has no holdings to compare, add some first"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("valuing portfolio: %v", err)})
+	}
+
+	report, err := analysis.CompareBenchmarks(ctx, h.pool, "Portfolio", series)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("comparing benchmarks: %v", err)})
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
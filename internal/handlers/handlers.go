@@ -4,15 +4,18 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/mauv0809/crispy-broccoli/internal/analysis"
 	"github.com/mauv0809/crispy-broccoli/internal/views"
 )
 
 type Handler struct {
-	// Add dependencies here (e.g., db pool, services)
+	strategies *analysis.Registry
 }
 
-func New() *Handler {
-	return &Handler{}
+func New(strategies *analysis.Registry) *Handler {
+	return &Handler{
+		strategies: strategies,
+	}
 }
 
 // Health returns application health status
@@ -29,7 +32,7 @@ func (h *Handler) Health(c echo.Context) error {
 }
 
 func (h *Handler) Index(c echo.Context) error {
-	return Render(c, http.StatusOK, views.Index())
+	return Render(c, http.StatusOK, views.Index(h.strategies.Names()))
 }
 
 func (h *Handler) Docs(c echo.Context) error {
@@ -1,30 +1,45 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"strings"
-	"sync"
-	"sync/atomic"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/mauv0809/crispy-broccoli/internal/db"
-	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+	"github.com/mauv0809/crispy-broccoli/internal/jobs"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+	"github.com/mauv0809/crispy-broccoli/internal/pipeline"
+	"github.com/mauv0809/crispy-broccoli/internal/scheduler"
+	"github.com/mauv0809/crispy-broccoli/internal/views"
+)
+
+const (
+	recentRunLimit  = 10  // Runs included in IngestStatus
+	runHistoryLimit = 100 // Runs listed on the history page
 )
 
 // IngestHandler handles data ingestion endpoints.
 type IngestHandler struct {
-	client *ingest.Client
-	repo   *db.Repository
+	pipeline    *pipeline.Pipeline
+	repo        *db.Repository
+	deadLetters *db.DeadLetterRepository
+	jobs        *jobs.Runner
+	scheduler   *scheduler.Scheduler
 }
 
 // NewIngestHandler creates a new ingest handler.
-func NewIngestHandler(client *ingest.Client, repo *db.Repository) *IngestHandler {
+func NewIngestHandler(p *pipeline.Pipeline, repo *db.Repository, deadLetters *db.DeadLetterRepository, runner *jobs.Runner, sched *scheduler.Scheduler) *IngestHandler {
 	return &IngestHandler{
-		client: client,
-		repo:   repo,
+		pipeline:    p,
+		repo:        repo,
+		deadLetters: deadLetters,
+		jobs:        runner,
+		scheduler:   sched,
 	}
 }
 
@@ -34,6 +49,7 @@ type IngestResponse struct {
 	Message string `json:"message"`
 	Count   int    `json:"count,omitempty"`
 	Elapsed string `json:"elapsed,omitempty"`
+	JobID   int64  `json:"job_id,omitempty"` // Set for background jobs, poll /admin/jobs/{id}
 }
 
 // IngestTickers handles POST /admin/ingest/tickers
@@ -44,6 +60,7 @@ type IngestResponse struct {
 // @Produce json
 // @Param ticker query string false "Comma-separated tickers (defaults to all)"
 // @Success 200 {object} IngestResponse
+// @Failure 409 {object} IngestResponse
 // @Failure 500 {object} IngestResponse
 // @Router /admin/ingest/tickers [post]
 func (h *IngestHandler) IngestTickers(c echo.Context) error {
@@ -51,36 +68,22 @@ func (h *IngestHandler) IngestTickers(c echo.Context) error {
 	start := time.Now()
 
 	// Parse optional ticker filter
-	var tickerFilter []string
-	if tickerParam := c.QueryParam("ticker"); tickerParam != "" {
-		tickerFilter = strings.Split(tickerParam, ",")
-		for i := range tickerFilter {
-			tickerFilter[i] = strings.TrimSpace(tickerFilter[i])
-		}
+	tickerFilter := pipeline.ParseList(c.QueryParam("ticker"))
+	if len(tickerFilter) > 0 {
 		log.Printf("Starting ticker ingestion for: %v", tickerFilter)
 	} else {
 		log.Println("Starting ticker ingestion (all tickers)...")
 	}
 
-	// Fetch tickers from API
-	tickers, err := h.client.FetchTickers(ctx, tickerFilter)
-	if err != nil {
-		log.Printf("Error fetching tickers: %v", err)
-		return c.JSON(http.StatusInternalServerError, IngestResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to fetch tickers: %v", err),
-		})
-	}
-
-	log.Printf("Fetched %d tickers from API", len(tickers))
-
-	// Upsert to database
-	count, err := h.repo.UpsertCompanies(ctx, tickers)
+	spec := jobs.Spec{Kind: "tickers", Endpoint: c.Path(), Params: pipeline.Params{Tickers: tickerFilter}}
+	count, err := h.jobs.Run(ctx, spec, func(ctx context.Context, p *jobs.Progress) (int, error) {
+		return h.pipeline.Tickers(ctx, tickerFilter, p)
+	})
 	if err != nil {
-		log.Printf("Error upserting companies: %v", err)
-		return c.JSON(http.StatusInternalServerError, IngestResponse{
+		log.Printf("Error ingesting tickers: %v", err)
+		return c.JSON(jobErrorStatus(err), IngestResponse{
 			Success: false,
-			Message: fmt.Sprintf("Failed to upsert companies: %v", err),
+			Message: fmt.Sprintf("Failed to ingest tickers: %v", err),
 		})
 	}
 
@@ -97,357 +100,254 @@ func (h *IngestHandler) IngestTickers(c echo.Context) error {
 
 // IngestFundamentals handles POST /admin/ingest/fundamentals
 // @Summary Ingest financial metrics
-// @Description Fetches fundamental data from SHARADAR/SF1 and upserts into financial_metrics table
+// @Description Starts a background job that fetches fundamental data from SHARADAR/SF1 and upserts into the financial_metrics table. Poll /admin/jobs/{id} for progress.
 // @Tags ingestion
 // @Accept json
 // @Produce json
 // @Param ticker query string false "Comma-separated tickers (defaults to all companies in DB)"
 // @Param dimension query string false "Comma-separated dimensions" default(ARQ,MRQ)
 // @Param full query boolean false "Fetch all history (default: incremental)"
-// @Success 200 {object} IngestResponse
+// @Success 202 {object} IngestResponse
 // @Failure 400 {object} IngestResponse
+// @Failure 409 {object} IngestResponse
 // @Failure 500 {object} IngestResponse
 // @Router /admin/ingest/fundamentals [post]
 func (h *IngestHandler) IngestFundamentals(c echo.Context) error {
-	ctx := c.Request().Context()
-	start := time.Now()
-
-	// Parse ticker filter - default to companies we have in DB
-	var tickerFilter []string
-	if tickerParam := c.QueryParam("ticker"); tickerParam != "" {
-		tickerFilter = strings.Split(tickerParam, ",")
-		for i := range tickerFilter {
-			tickerFilter[i] = strings.TrimSpace(tickerFilter[i])
-		}
-	} else {
-		// Default to all companies in our database
-		var err error
-		tickerFilter, err = h.repo.GetAllTickers(ctx)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, IngestResponse{
-				Success: false,
-				Message: fmt.Sprintf("Failed to get tickers: %v", err),
-			})
-		}
+	params := pipeline.Params{
+		Tickers:    pipeline.ParseList(c.QueryParam("ticker")),
+		Dimensions: pipeline.ParseList(c.QueryParam("dimension")),
+		Full:       c.QueryParam("full") == "true",
 	}
 
-	if len(tickerFilter) == 0 {
-		return c.JSON(http.StatusBadRequest, IngestResponse{
-			Success: false,
-			Message: "No companies in database. Run /admin/ingest/tickers first.",
-		})
+	return h.startJob(c, "fundamentals", params, func(ctx context.Context, p *jobs.Progress) (int, error) {
+		return h.pipeline.Fundamentals(ctx, params, p)
+	})
+}
+
+// IngestDaily handles POST /admin/ingest/daily
+// @Summary Ingest daily prices
+// @Description Starts a background job that fetches daily price/fundamental data from SHARADAR/DAILY. If no ticker specified, fetches for all DB companies. Poll /admin/jobs/{id} for progress.
+// @Tags ingestion
+// @Accept json
+// @Produce json
+// @Param ticker query string false "Comma-separated tickers (defaults to all companies in DB)"
+// @Param full query boolean false "Fetch all history (default: incremental)"
+// @Success 202 {object} IngestResponse
+// @Failure 400 {object} IngestResponse
+// @Failure 409 {object} IngestResponse
+// @Failure 500 {object} IngestResponse
+// @Router /admin/ingest/daily [post]
+func (h *IngestHandler) IngestDaily(c echo.Context) error {
+	params := pipeline.Params{
+		Tickers: pipeline.ParseList(c.QueryParam("ticker")),
+		Full:    c.QueryParam("full") == "true",
 	}
 
-	// Parse query params
-	dimensionParam := c.QueryParam("dimension")
-	if dimensionParam == "" {
-		dimensionParam = "ARQ,MRQ"
+	return h.startJob(c, "daily", params, func(ctx context.Context, p *jobs.Progress) (int, error) {
+		return h.pipeline.Daily(ctx, params, p)
+	})
+}
+
+// IngestPrices handles POST /admin/ingest/prices
+// @Summary Ingest equity prices
+// @Description Starts a background job that fetches split-adjusted OHLC prices from SHARADAR/SEP, which backtests and portfolio valuation use. If no ticker specified, fetches for all DB companies. Poll /admin/jobs/{id} for progress.
+// @Tags ingestion
+// @Accept json
+// @Produce json
+// @Param ticker query string false "Comma-separated tickers (defaults to all companies in DB)"
+// @Param full query boolean false "Fetch all history (default: incremental)"
+// @Success 202 {object} IngestResponse
+// @Failure 400 {object} IngestResponse
+// @Failure 409 {object} IngestResponse
+// @Failure 500 {object} IngestResponse
+// @Router /admin/ingest/prices [post]
+func (h *IngestHandler) IngestPrices(c echo.Context) error {
+	params := pipeline.Params{
+		Tickers: pipeline.ParseList(c.QueryParam("ticker")),
+		Full:    c.QueryParam("full") == "true",
 	}
-	dimensions := strings.Split(dimensionParam, ",")
 
-	fullFetch := c.QueryParam("full") == "true"
+	return h.startJob(c, "prices", params, func(ctx context.Context, p *jobs.Progress) (int, error) {
+		return h.pipeline.Prices(ctx, params, p)
+	})
+}
 
-	log.Printf("Starting fundamentals ingestion (tickers: %d, dimensions: %v, full: %v)...", len(tickerFilter), dimensions, fullFetch)
+// IngestBenchmarks handles POST /admin/ingest/benchmarks
+// @Summary Ingest benchmark prices
+// @Description Starts a background job that fetches fund prices for configured benchmarks (e.g., SPY) from SHARADAR/SFP. Poll /admin/jobs/{id} for progress.
+// @Tags ingestion
+// @Accept json
+// @Produce json
+// @Param full query boolean false "Fetch all history (default: incremental)"
+// @Success 202 {object} IngestResponse
+// @Failure 400 {object} IngestResponse
+// @Failure 409 {object} IngestResponse
+// @Failure 500 {object} IngestResponse
+// @Router /admin/ingest/benchmarks [post]
+func (h *IngestHandler) IngestBenchmarks(c echo.Context) error {
+	ctx := c.Request().Context()
 
-	// Check if we have companies first
-	companyCount, err := h.repo.GetCompanyCount(ctx)
+	tickers, err := h.repo.GetBenchmarkTickers(ctx)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, IngestResponse{
 			Success: false,
-			Message: fmt.Sprintf("Failed to check companies: %v", err),
+			Message: fmt.Sprintf("Failed to get benchmark tickers: %v", err),
 		})
 	}
 
-	if companyCount == 0 {
+	if len(tickers) == 0 {
 		return c.JSON(http.StatusBadRequest, IngestResponse{
 			Success: false,
-			Message: "No companies in database. Run /admin/ingest/tickers first.",
+			Message: "No benchmarks configured in database",
 		})
 	}
 
-	var totalCount atomic.Int64
-
-	for _, dimension := range dimensions {
-		dimension = strings.TrimSpace(dimension)
-		if dimension == "" {
-			continue
-		}
-
-		// Determine since date for incremental fetch
-		var since time.Time
-		if !fullFetch {
-			since, _ = h.repo.GetLastSharadarUpdate(ctx, "financial_metrics")
-			log.Printf("Incremental fetch for %s since %v", dimension, since)
-		}
+	params := pipeline.Params{Full: c.QueryParam("full") == "true"}
 
-		// Stream batches with parallel API fetches (5 concurrent) and parallel upserts (3 concurrent)
-		const maxAPIParallel = 5
-		batchCh := h.client.FetchSF1Stream(ctx, tickerFilter, dimension, since, maxAPIParallel)
-
-		var wg sync.WaitGroup
-		var fetchErr error
-		sem := make(chan struct{}, 3) // Limit concurrent DB writes
-
-		for batch := range batchCh {
-			if batch.Error != nil {
-				fetchErr = batch.Error
-				log.Printf("Error fetching SF1 batch (%s): %v", dimension, batch.Error)
-				break
-			}
-
-			if len(batch.Rows) == 0 {
-				continue
-			}
-
-			sem <- struct{}{} // Acquire slot (blocks if 3 upserts running)
-
-			wg.Add(1)
-			go func(rows []ingest.SF1Row) {
-				defer wg.Done()
-				defer func() { <-sem }()
-
-				count, err := h.repo.UpsertFinancialMetrics(ctx, rows)
-				if err != nil {
-					log.Printf("Error upserting metrics batch (%s): %v", dimension, err)
-				}
-				totalCount.Add(int64(count))
-				log.Printf("Upserted %d metrics for %s", count, dimension)
-			}(batch.Rows)
-		}
-
-		// Wait for all upserts to complete before moving to next dimension
-		wg.Wait()
-
-		if fetchErr != nil {
-			return c.JSON(http.StatusInternalServerError, IngestResponse{
-				Success: false,
-				Message: fmt.Sprintf("Failed to fetch SF1 (%s): %v", dimension, fetchErr),
-			})
-		}
-	}
-
-	elapsed := time.Since(start)
-	count := int(totalCount.Load())
-	log.Printf("Fundamentals ingestion complete: %d metrics in %v", count, elapsed)
-
-	return c.JSON(http.StatusOK, IngestResponse{
-		Success: true,
-		Message: fmt.Sprintf("Successfully ingested %d financial metrics", count),
-		Count:   count,
-		Elapsed: elapsed.String(),
+	return h.startJob(c, "benchmarks", params, func(ctx context.Context, p *jobs.Progress) (int, error) {
+		return h.pipeline.Benchmarks(ctx, params, p)
 	})
 }
 
-// IngestDaily handles POST /admin/ingest/daily
-// @Summary Ingest daily prices
-// @Description Fetches daily price/fundamental data from SHARADAR/DAILY. If no ticker specified, fetches for all DB companies.
+// IngestSP500 handles POST /admin/ingest/sp500
+// @Summary Ingest S&P 500 membership history
+// @Description Fetches the full added/removed history and current constituents from SHARADAR/SP500
 // @Tags ingestion
 // @Accept json
 // @Produce json
-// @Param ticker query string false "Comma-separated tickers (defaults to all companies in DB)"
-// @Param full query boolean false "Fetch all history (default: incremental)"
 // @Success 200 {object} IngestResponse
-// @Failure 400 {object} IngestResponse
+// @Failure 409 {object} IngestResponse
 // @Failure 500 {object} IngestResponse
-// @Router /admin/ingest/daily [post]
-func (h *IngestHandler) IngestDaily(c echo.Context) error {
+// @Router /admin/ingest/sp500 [post]
+func (h *IngestHandler) IngestSP500(c echo.Context) error {
 	ctx := c.Request().Context()
 	start := time.Now()
 
-	// Parse ticker filter - default to all companies in DB
-	var tickers []string
-	var fetchAllFromDB bool
-	if tickerParam := c.QueryParam("ticker"); tickerParam != "" {
-		tickers = strings.Split(tickerParam, ",")
-		for i := range tickers {
-			tickers[i] = strings.TrimSpace(tickers[i])
-		}
-	} else {
-		// Fetch all tickers from database
-		var err error
-		tickers, err = h.repo.GetAllTickers(ctx)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, IngestResponse{
-				Success: false,
-				Message: fmt.Sprintf("Failed to get tickers: %v", err),
-			})
-		}
-		fetchAllFromDB = true
-	}
+	log.Println("Starting S&P 500 membership ingestion...")
 
-	if len(tickers) == 0 {
-		return c.JSON(http.StatusBadRequest, IngestResponse{
+	spec := jobs.Spec{Kind: "sp500", Endpoint: c.Path(), Params: pipeline.Params{}}
+	count, err := h.jobs.Run(ctx, spec, func(ctx context.Context, p *jobs.Progress) (int, error) {
+		return h.pipeline.SP500(ctx, p)
+	})
+	if err != nil {
+		log.Printf("Error ingesting SP500 membership: %v", err)
+		return c.JSON(jobErrorStatus(err), IngestResponse{
 			Success: false,
-			Message: "No companies in database. Run /admin/ingest/tickers first.",
+			Message: fmt.Sprintf("Failed to ingest SP500 membership: %v", err),
 		})
 	}
 
-	fullFetch := c.QueryParam("full") == "true"
-
-	log.Printf("Starting daily price ingestion (tickers: %d, full: %v)...", len(tickers), fullFetch)
-
-	// Determine since date for incremental fetch
-	var since time.Time
-	if !fullFetch {
-		since, _ = h.repo.GetLastSharadarUpdate(ctx, "daily_prices")
-		log.Printf("Incremental fetch since %v", since)
-	}
-
-	// Stream batches with parallel API fetches (5 concurrent) and parallel upserts (3 concurrent)
-	const maxAPIParallel = 5
-	const maxDBParallel = 3
-
-	batchCh := h.client.FetchDailyStream(ctx, tickers, since, maxAPIParallel)
-
-	var totalCount atomic.Int64
-	var wg sync.WaitGroup
-	var fetchErr error
-	sem := make(chan struct{}, maxDBParallel)
-
-	for batch := range batchCh {
-		if batch.Error != nil {
-			fetchErr = batch.Error
-			log.Printf("Error fetching daily batch: %v", batch.Error)
-			continue // Don't stop - try other batches
-		}
-
-		if len(batch.Rows) == 0 {
-			continue
-		}
-
-		// Filter to valid tickers only if we're fetching specific tickers (not all from DB)
-		rowsToUpsert := batch.Rows
-		if !fetchAllFromDB {
-			validRows := make([]ingest.DailyRow, 0, len(batch.Rows))
-			for _, row := range batch.Rows {
-				exists, err := h.repo.CompanyExists(ctx, row.Ticker)
-				if err == nil && exists {
-					validRows = append(validRows, row)
-				}
-			}
-			rowsToUpsert = validRows
-		}
-
-		if len(rowsToUpsert) == 0 {
-			continue
-		}
-
-		sem <- struct{}{} // Acquire DB slot
-
-		wg.Add(1)
-		go func(rows []ingest.DailyRow) {
-			defer wg.Done()
-			defer func() { <-sem }()
-
-			count, err := h.repo.UpsertDailyPrices(ctx, rows)
-			if err != nil {
-				log.Printf("Error upserting daily prices: %v", err)
-			}
-			totalCount.Add(int64(count))
-			log.Printf("Upserted %d daily prices", count)
-		}(rowsToUpsert)
-	}
-
-	wg.Wait()
-
-	count := int(totalCount.Load())
 	elapsed := time.Since(start)
-
-	if fetchErr != nil && count == 0 {
-		return c.JSON(http.StatusInternalServerError, IngestResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to fetch daily prices: %v", fetchErr),
-		})
-	}
-
-	log.Printf("Daily price ingestion complete: %d prices in %v", count, elapsed)
+	log.Printf("SP500 ingestion complete: %d rows in %v", count, elapsed)
 
 	return c.JSON(http.StatusOK, IngestResponse{
 		Success: true,
-		Message: fmt.Sprintf("Successfully ingested %d daily prices", count),
+		Message: fmt.Sprintf("Successfully ingested %d S&P 500 membership rows", count),
 		Count:   count,
 		Elapsed: elapsed.String(),
 	})
 }
 
-// IngestBenchmarks handles POST /admin/ingest/benchmarks
-// @Summary Ingest benchmark prices
-// @Description Fetches daily data for configured benchmarks (e.g., SPY) from SHARADAR/DAILY
-// @Tags ingestion
-// @Accept json
-// @Produce json
-// @Param full query boolean false "Fetch all history (default: incremental)"
-// @Success 200 {object} IngestResponse
-// @Failure 400 {object} IngestResponse
-// @Failure 500 {object} IngestResponse
-// @Router /admin/ingest/benchmarks [post]
-func (h *IngestHandler) IngestBenchmarks(c echo.Context) error {
+// startJob checks that companies exist, then runs fn as a background job.
+// Responds 409 while another ingestion run is in progress.
+func (h *IngestHandler) startJob(c echo.Context, kind string, params pipeline.Params, fn jobs.Func) error {
 	ctx := c.Request().Context()
-	start := time.Now()
 
-	// Get benchmark tickers from database
-	tickers, err := h.repo.GetBenchmarkTickers(ctx)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, IngestResponse{
+	if err := h.pipeline.CheckCompanies(ctx); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, pipeline.ErrNoCompanies) {
+			status = http.StatusBadRequest
+		}
+		return c.JSON(status, IngestResponse{
 			Success: false,
-			Message: fmt.Sprintf("Failed to get benchmark tickers: %v", err),
+			Message: err.Error(),
 		})
 	}
 
-	if len(tickers) == 0 {
-		return c.JSON(http.StatusBadRequest, IngestResponse{
+	id, err := h.jobs.Start(ctx, jobs.Spec{Kind: kind, Endpoint: c.Path(), Params: params}, fn)
+	if err != nil {
+		log.Printf("Error starting %s job: %v", kind, err)
+		return c.JSON(jobErrorStatus(err), IngestResponse{
 			Success: false,
-			Message: "No benchmarks configured in database",
+			Message: fmt.Sprintf("Failed to start %s job: %v", kind, err),
 		})
 	}
 
-	fullFetch := c.QueryParam("full") == "true"
+	return c.JSON(http.StatusAccepted, IngestResponse{
+		Success: true,
+		Message: fmt.Sprintf("Started %s ingestion job %d", kind, id),
+		JobID:   id,
+	})
+}
 
-	log.Printf("Starting benchmark ingestion (tickers: %v, full: %v)...", tickers, fullFetch)
+// jobErrorStatus is 409 Conflict for a job refused because another ingestion
+// run holds the lock, 500 for anything else.
+func jobErrorStatus(err error) int {
+	if errors.Is(err, jobs.ErrAlreadyRunning) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
 
-	// Determine since date for incremental fetch
-	var since time.Time
-	if !fullFetch {
-		since, _ = h.repo.GetLastBenchmarkUpdate(ctx)
-		log.Printf("Incremental fetch since %v", since)
+// GetJob handles GET /admin/jobs/:id
+// @Summary Get ingestion job progress
+// @Description Returns a background job's status, batches done, rows upserted (split into inserted, updated and unchanged when bulk-copied), errors and elapsed time
+// @Tags ingestion
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.IngestRun
+// @Failure 400 {object} IngestResponse
+// @Failure 404 {object} IngestResponse
+// @Failure 500 {object} IngestResponse
+// @Router /admin/jobs/{id} [get]
+func (h *IngestHandler) GetJob(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, IngestResponse{Success: false, Message: "invalid job id"})
 	}
 
-	// Fetch from API (using same SHARADAR/DAILY endpoint)
-	rows, err := h.client.FetchDaily(ctx, tickers, since)
+	var job *models.IngestRun
+	job, err = h.jobs.Get(c.Request().Context(), id)
+	if errors.Is(err, db.ErrRunNotFound) {
+		return c.JSON(http.StatusNotFound, IngestResponse{Success: false, Message: err.Error()})
+	}
 	if err != nil {
-		log.Printf("Error fetching benchmark prices: %v", err)
-		return c.JSON(http.StatusInternalServerError, IngestResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to fetch benchmark prices: %v", err),
-		})
+		return c.JSON(http.StatusInternalServerError, IngestResponse{Success: false, Message: err.Error()})
 	}
 
-	log.Printf("Fetched %d benchmark price rows", len(rows))
+	return c.JSON(http.StatusOK, job)
+}
 
-	// Upsert to benchmark_prices table
-	count, err := h.repo.UpsertBenchmarkPrices(ctx, rows)
+// CancelJob handles DELETE /admin/jobs/:id
+// @Summary Cancel an ingestion job
+// @Description Cancels a running background job through its context. Batches already upserted are kept.
+// @Tags ingestion
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 202 {object} IngestResponse
+// @Failure 400 {object} IngestResponse
+// @Failure 409 {object} IngestResponse
+// @Router /admin/jobs/{id} [delete]
+func (h *IngestHandler) CancelJob(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		log.Printf("Error upserting benchmark prices: %v", err)
-		return c.JSON(http.StatusInternalServerError, IngestResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to upsert benchmark prices: %v", err),
-		})
+		return c.JSON(http.StatusBadRequest, IngestResponse{Success: false, Message: "invalid job id"})
 	}
 
-	elapsed := time.Since(start)
-	log.Printf("Benchmark ingestion complete: %d prices in %v", count, elapsed)
+	if err := h.jobs.Cancel(id); err != nil {
+		return c.JSON(http.StatusConflict, IngestResponse{Success: false, Message: err.Error()})
+	}
 
-	return c.JSON(http.StatusOK, IngestResponse{
+	return c.JSON(http.StatusAccepted, IngestResponse{
 		Success: true,
-		Message: fmt.Sprintf("Successfully ingested %d benchmark prices", count),
-		Count:   count,
-		Elapsed: elapsed.String(),
+		Message: fmt.Sprintf("Cancelling job %d", id),
+		JobID:   id,
 	})
 }
 
 // IngestStatus handles GET /admin/ingest/status
 // @Summary Get ingestion status
-// @Description Returns current data counts and last update timestamps
+// @Description Returns current data counts, last update timestamps, the ingestion schedule with its next run time, the most recent ingestion runs and today's API usage against the daily quota
 // @Tags ingestion
 // @Produce json
 // @Success 200 {object} map[string]interface{}
@@ -458,20 +358,142 @@ func (h *IngestHandler) IngestStatus(c echo.Context) error {
 	companyCount, _ := h.repo.GetCompanyCount(ctx)
 	metricCount, _ := h.repo.GetMetricCount(ctx)
 	priceCount, _ := h.repo.GetDailyPriceCount(ctx)
-	benchmarkCount, _ := h.repo.GetBenchmarkPriceCount(ctx)
+	equityPriceCount, _ := h.repo.GetEquityPriceCount(ctx)
+	fundPriceCount, _ := h.repo.GetFundPriceCount(ctx)
+	sp500Count, _ := h.repo.GetSP500EventCount(ctx)
 
 	lastMetricUpdate, _ := h.repo.GetLastSharadarUpdate(ctx, "financial_metrics")
 	lastPriceUpdate, _ := h.repo.GetLastSharadarUpdate(ctx, "daily_prices")
+	lastEquityPriceUpdate, _ := h.repo.GetLastSharadarUpdate(ctx, "equity_prices")
 	lastBenchmarkUpdate, _ := h.repo.GetLastBenchmarkUpdate(ctx)
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"companies":             companyCount,
-		"metrics":               metricCount,
-		"prices":                priceCount,
-		"benchmark_prices":      benchmarkCount,
-		"last_metric_update":    lastMetricUpdate.Format("2006-01-02"),
-		"last_price_update":     lastPriceUpdate.Format("2006-01-02"),
-		"last_benchmark_update": lastBenchmarkUpdate.Format("2006-01-02"),
+	recentRuns, err := h.jobs.List(ctx, recentRunLimit)
+	if err != nil {
+		log.Printf("Error listing ingest runs: %v", err)
+	}
+	deadLetterCount, _ := h.deadLetters.CountUnresolved(ctx)
+
+	status := map[string]interface{}{
+		"companies":                companyCount,
+		"metrics":                  metricCount,
+		"prices":                   priceCount,
+		"equity_prices":            equityPriceCount,
+		"fund_prices":              fundPriceCount,
+		"sp500_events":             sp500Count,
+		"last_metric_update":       lastMetricUpdate.Format("2006-01-02"),
+		"last_price_update":        lastPriceUpdate.Format("2006-01-02"),
+		"last_equity_price_update": lastEquityPriceUpdate.Format("2006-01-02"),
+		"last_benchmark_update":    lastBenchmarkUpdate.Format("2006-01-02"),
+		"schedule":                 h.scheduler.Status(),
+		"recent_runs":              recentRuns,
+		"dead_letters":             deadLetterCount,
+	}
+	if usage, ok := h.pipeline.Usage(ctx); ok {
+		status["api_usage"] = usage
+		status["api_calls_left"] = usage.CallsLeft() // -1 = unlimited
+		status["api_rows_left"] = usage.RowsLeft()
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// deadLetterFilter reads run_id, table and limit query parameters.
+func deadLetterFilter(c echo.Context) (db.DeadLetterFilter, error) {
+	f := db.DeadLetterFilter{Table: c.QueryParam("table")}
+	if v := c.QueryParam("run_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return f, fmt.Errorf("invalid run_id %q", v)
+		}
+		f.RunID = id
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return f, fmt.Errorf("invalid limit %q", v)
+		}
+		f.Limit = limit
+	}
+	return f, nil
+}
+
+// ListDeadLetters handles GET /admin/dead-letters
+// @Summary List dead-lettered rows
+// @Description Returns unresolved values nulled for overflowing their column and rows of failed database batches, with the row as fetched, the reason and the run ID
+// @Tags ingestion
+// @Produce json
+// @Param run_id query int false "Only letters from this run"
+// @Param table query string false "Only letters for this table (financial_metrics, daily_prices, equity_prices, fund_prices)"
+// @Param limit query int false "Maximum letters returned" default(100)
+// @Success 200 {array} models.DeadLetter
+// @Failure 400 {object} IngestResponse
+// @Failure 500 {object} IngestResponse
+// @Router /admin/dead-letters [get]
+func (h *IngestHandler) ListDeadLetters(c echo.Context) error {
+	filter, err := deadLetterFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, IngestResponse{Success: false, Message: err.Error()})
+	}
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	}
+
+	var letters []models.DeadLetter
+	letters, err = h.deadLetters.ListDeadLetters(c.Request().Context(), filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, IngestResponse{Success: false, Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, letters)
+}
+
+// RetryDeadLetters handles POST /admin/dead-letters/retry
+// @Summary Retry dead-lettered rows
+// @Description Starts a background job that upserts unresolved dead-lettered rows again, e.g. after a migration widens an overflowing column. Rows are never stored over data updated since they were fetched. Rows stored or skipped as stale are resolved; the rest record the new reason. Poll /admin/jobs/{id} for progress.
+// @Tags ingestion
+// @Produce json
+// @Param run_id query int false "Only letters from this run"
+// @Param table query string false "Only letters for this table (financial_metrics, daily_prices, equity_prices, fund_prices)"
+// @Param limit query int false "Maximum letters retried (defaults to all)"
+// @Success 202 {object} IngestResponse
+// @Failure 400 {object} IngestResponse
+// @Failure 409 {object} IngestResponse
+// @Failure 500 {object} IngestResponse
+// @Router /admin/dead-letters/retry [post]
+func (h *IngestHandler) RetryDeadLetters(c echo.Context) error {
+	filter, err := deadLetterFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, IngestResponse{Success: false, Message: err.Error()})
+	}
+
+	// A job like any other ingestion, so it never writes rows alongside one
+	spec := jobs.Spec{Kind: "dead_letters", Endpoint: c.Path(), Params: filter}
+	id, err := h.jobs.Start(c.Request().Context(), spec, func(ctx context.Context, p *jobs.Progress) (int, error) {
+		result, err := h.pipeline.RetryDeadLetters(ctx, h.deadLetters, filter, p)
+		return result.Resolved, err
+	})
+	if err != nil {
+		log.Printf("Error starting dead-letter retry: %v", err)
+		return c.JSON(jobErrorStatus(err), IngestResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to start dead-letter retry: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusAccepted, IngestResponse{
+		Success: true,
+		Message: fmt.Sprintf("Started dead-letter retry job %d", id),
+		JobID:   id,
 	})
 }
 
+// RunsPage handles GET /admin/runs, the ingestion run history.
+func (h *IngestHandler) RunsPage(c echo.Context) error {
+	runs, err := h.jobs.List(c.Request().Context(), runHistoryLimit)
+	if err != nil {
+		log.Printf("Error listing ingest runs: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load ingest runs")
+	}
+
+	return Render(c, http.StatusOK, views.IngestRuns(runs))
+}
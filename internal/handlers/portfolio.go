@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/mauv0809/crispy-broccoli/internal/analysis"
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+	"github.com/mauv0809/crispy-broccoli/internal/views"
+	"github.com/shopspring/decimal"
+)
+
+// PortfolioHandler manages portfolio holdings.
+type PortfolioHandler struct {
+	pool *pgxpool.Pool
+	repo *db.PortfolioRepository
+}
+
+// NewPortfolioHandler creates a new portfolio handler.
+func NewPortfolioHandler(pool *pgxpool.Pool) *PortfolioHandler {
+	return &PortfolioHandler{
+		pool: pool,
+		repo: db.NewPortfolioRepository(pool),
+	}
+}
+
+// HoldingRequest is the body for creating or updating a holding.
+type HoldingRequest struct {
+	Ticker       string          `json:"ticker" example:"AAPL"`
+	SharesOwned  decimal.Decimal `json:"shares_owned"`
+	CostBasis    decimal.Decimal `json:"cost_basis"`              // Total cost of the lot
+	TargetWeight decimal.Decimal `json:"target_weight"`           // Fraction of the portfolio (0-1)
+	AcquiredDate string          `json:"acquired_date,omitempty"` // YYYY-MM-DD
+}
+
+// Page handles GET /portfolio
+func (h *PortfolioHandler) Page(c echo.Context) error {
+	summary, err := analysis.SummarizePortfolio(c.Request().Context(), h.pool)
+	if err != nil {
+		log.Printf("Error loading portfolio: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load portfolio")
+	}
+
+	return Render(c, http.StatusOK, views.Portfolio(summary))
+}
+
+// Table handles GET /portfolio/table and returns the holdings as an HTML partial.
+// Pass editable=true for the version with edit and delete controls.
+func (h *PortfolioHandler) Table(c echo.Context) error {
+	return h.renderTable(c, c.QueryParam("editable") == "true")
+}
+
+// EditRow handles GET /portfolio/:id/edit and returns an inline edit form for one holding.
+func (h *PortfolioHandler) EditRow(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "invalid id")
+	}
+
+	holding, err := h.repo.Get(c.Request().Context(), id)
+	if err != nil {
+		return h.renderError(c, fmt.Sprintf("Failed to load holding: %v", err))
+	}
+
+	return Render(c, http.StatusOK, views.PortfolioEditRow(holding))
+}
+
+// List handles GET /api/portfolio
+// @Summary List holdings
+// @Description Returns all rows of the portfolio table
+// @Tags portfolio
+// @Produce json
+// @Success 200 {array} models.PortfolioHolding
+// @Failure 500 {object} ErrorResponse
+// @Router /api/portfolio [get]
+func (h *PortfolioHandler) List(c echo.Context) error {
+	holdings, err := h.repo.List(c.Request().Context())
+	if err != nil {
+		log.Printf("Error listing holdings: %v", err)
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("listing holdings: %v", err)})
+	}
+	if holdings == nil {
+		holdings = []models.PortfolioHolding{}
+	}
+
+	return c.JSON(http.StatusOK, holdings)
+}
+
+// Get handles GET /api/portfolio/:id
+// @Summary Get a holding
+// @Tags portfolio
+// @Produce json
+// @Param id path int true "Holding ID"
+// @Success 200 {object} models.PortfolioHolding
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/portfolio/{id} [get]
+func (h *PortfolioHandler) Get(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	holding, err := h.repo.Get(c.Request().Context(), id)
+	if errors.Is(err, db.ErrHoldingNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, holding)
+}
+
+// Create handles POST /api/portfolio
+// @Summary Add a holding
+// @Description Adds a lot to the portfolio. The ticker must be a known company. Accepts JSON or form data; HTMX requests get the updated holdings table back.
+// @Tags portfolio
+// @Accept json
+// @Produce json
+// @Param holding body HoldingRequest true "Holding"
+// @Success 201 {object} models.PortfolioHolding
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/portfolio [post]
+func (h *PortfolioHandler) Create(c echo.Context) error {
+	holding, err := bindHolding(c)
+	if err != nil {
+		return h.fail(c, http.StatusBadRequest, err.Error())
+	}
+
+	err = h.repo.Create(c.Request().Context(), holding)
+	if errors.Is(err, db.ErrUnknownTicker) {
+		return h.fail(c, http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		log.Printf("Error adding holding %s: %v", holding.Ticker, err)
+		return h.fail(c, http.StatusInternalServerError, fmt.Sprintf("adding holding: %v", err))
+	}
+
+	log.Printf("Added holding %d: %s x %s", holding.ID, holding.Ticker, holding.SharesOwned)
+
+	if isHTMX(c) {
+		return h.renderTable(c, true)
+	}
+	return c.JSON(http.StatusCreated, holding)
+}
+
+// Update handles PUT /api/portfolio/:id
+// @Summary Edit a holding
+// @Description Replaces the shares, cost basis, target weight and acquired date of a holding. Accepts JSON or form data.
+// @Tags portfolio
+// @Accept json
+// @Produce json
+// @Param id path int true "Holding ID"
+// @Param holding body HoldingRequest true "Holding"
+// @Success 200 {object} models.PortfolioHolding
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/portfolio/{id} [put]
+func (h *PortfolioHandler) Update(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.fail(c, http.StatusBadRequest, "invalid id")
+	}
+
+	holding, err := bindHolding(c)
+	if err != nil {
+		return h.fail(c, http.StatusBadRequest, err.Error())
+	}
+	holding.ID = id
+
+	err = h.repo.Update(c.Request().Context(), holding)
+	if errors.Is(err, db.ErrHoldingNotFound) {
+		return h.fail(c, http.StatusNotFound, err.Error())
+	}
+	if errors.Is(err, db.ErrUnknownTicker) {
+		return h.fail(c, http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		log.Printf("Error updating holding %d: %v", id, err)
+		return h.fail(c, http.StatusInternalServerError, fmt.Sprintf("updating holding: %v", err))
+	}
+
+	if isHTMX(c) {
+		return h.renderTable(c, true)
+	}
+	return c.JSON(http.StatusOK, holding)
+}
+
+// Delete handles DELETE /api/portfolio/:id
+// @Summary Remove a holding
+// @Tags portfolio
+// @Param id path int true "Holding ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/portfolio/{id} [delete]
+func (h *PortfolioHandler) Delete(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.fail(c, http.StatusBadRequest, "invalid id")
+	}
+
+	err = h.repo.Delete(c.Request().Context(), id)
+	if errors.Is(err, db.ErrHoldingNotFound) {
+		return h.fail(c, http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		log.Printf("Error deleting holding %d: %v", id, err)
+		return h.fail(c, http.StatusInternalServerError, fmt.Sprintf("deleting holding: %v", err))
+	}
+
+	log.Printf("Deleted holding %d", id)
+
+	if isHTMX(c) {
+		return h.renderTable(c, true)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *PortfolioHandler) renderTable(c echo.Context, editable bool) error {
+	summary, err := analysis.SummarizePortfolio(c.Request().Context(), h.pool)
+	if err != nil {
+		log.Printf("Error loading portfolio: %v", err)
+		return h.renderError(c, fmt.Sprintf("Failed to load portfolio: %v", err))
+	}
+
+	return Render(c, http.StatusOK, views.PortfolioTable(summary, editable))
+}
+
+// renderError sends an error partial to the form's error slot instead of
+// replacing the table. Rendered with 200 since HTMX only swaps successful responses.
+func (h *PortfolioHandler) renderError(c echo.Context, msg string) error {
+	c.Response().Header().Set("HX-Retarget", "#portfolio-error")
+	c.Response().Header().Set("HX-Reswap", "innerHTML")
+	return Render(c, http.StatusOK, views.AnalysisError(msg))
+}
+
+// fail responds with a JSON error, or an error partial for HTMX requests.
+func (h *PortfolioHandler) fail(c echo.Context, status int, msg string) error {
+	if isHTMX(c) {
+		return h.renderError(c, msg)
+	}
+	return c.JSON(status, ErrorResponse{Error: msg})
+}
+
+func isHTMX(c echo.Context) bool {
+	return c.Request().Header.Get("HX-Request") == "true"
+}
+
+// bindHolding reads a holding from a JSON body or form fields and validates it.
+func bindHolding(c echo.Context) (*models.PortfolioHolding, error) {
+	var req HoldingRequest
+
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+	} else {
+		req.Ticker = c.FormValue("ticker")
+		req.AcquiredDate = c.FormValue("acquired_date")
+
+		fields := []struct {
+			name string
+			dst  *decimal.Decimal
+		}{
+			{"shares_owned", &req.SharesOwned},
+			{"cost_basis", &req.CostBasis},
+			{"target_weight", &req.TargetWeight},
+		}
+		for _, f := range fields {
+			v := strings.TrimSpace(c.FormValue(f.name))
+			if v == "" {
+				continue
+			}
+			d, err := decimal.NewFromString(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s", f.name)
+			}
+			*f.dst = d
+		}
+	}
+
+	holding := &models.PortfolioHolding{
+		Ticker:       strings.ToUpper(strings.TrimSpace(req.Ticker)),
+		SharesOwned:  req.SharesOwned,
+		CostBasis:    req.CostBasis,
+		TargetWeight: req.TargetWeight,
+	}
+
+	if holding.Ticker == "" {
+		return nil, fmt.Errorf("ticker is required")
+	}
+	if holding.SharesOwned.IsNegative() {
+		return nil, fmt.Errorf("shares_owned must not be negative")
+	}
+	if holding.CostBasis.IsNegative() {
+		return nil, fmt.Errorf("cost_basis must not be negative")
+	}
+	if holding.TargetWeight.IsNegative() || holding.TargetWeight.GreaterThan(decimal.NewFromInt(1)) {
+		return nil, fmt.Errorf("target_weight must be between 0 and 1")
+	}
+
+	if req.AcquiredDate != "" {
+		acquired, err := time.Parse("2006-01-02", req.AcquiredDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid acquired_date")
+		}
+		holding.AcquiredDate = &acquired
+	}
+
+	return holding, nil
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/mauv0809/crispy-broccoli/internal/analysis"
+	"github.com/mauv0809/crispy-broccoli/internal/views"
+)
+
+// RebalanceHandler calculates trades to bring the portfolio to its target weights.
+type RebalanceHandler struct {
+	pool *pgxpool.Pool
+}
+
+// NewRebalanceHandler creates a new rebalance handler.
+func NewRebalanceHandler(pool *pgxpool.Pool) *RebalanceHandler {
+	return &RebalanceHandler{pool: pool}
+}
+
+// Rebalance handles GET /api/rebalance
+// @Summary Calculate rebalancing trades
+// @Description Values holdings at the latest close and returns Buy/Add/Trim/Sell actions with dollar amounts and whole-share quantities
+// @Tags portfolio
+// @Produce json
+// @Success 200 {object} analysis.RebalancePlan
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/rebalance [get]
+func (h *RebalanceHandler) Rebalance(c echo.Context) error {
+	plan, err := analysis.RebalancePortfolio(c.Request().Context(), h.pool)
+	if errors.Is(err, analysis.ErrInvalidTargets) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	if err != nil {
+		log.Printf("Error calculating rebalance: %v", err)
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("calculating rebalance: %v", err)})
+	}
+
+	return c.JSON(http.StatusOK, plan)
+}
+
+// RebalanceTable handles GET /rebalance and returns the plan as an HTML partial.
+func (h *RebalanceHandler) RebalanceTable(c echo.Context) error {
+	plan, err := analysis.RebalancePortfolio(c.Request().Context(), h.pool)
+	if err != nil {
+		log.Printf("Error calculating rebalance: %v", err)
+		// Rendered with 200 since HTMX only swaps successful responses
+		return Render(c, http.StatusOK, views.AnalysisError(fmt.Sprintf("Failed to calculate rebalance: %v", err)))
+	}
+
+	return Render(c, http.StatusOK, views.RebalanceTable(plan))
+}
@@ -0,0 +1,147 @@
+package ingest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CassetteMode selects whether a cassette records or replays API pages.
+type CassetteMode string
+
+const (
+	CassetteRecord CassetteMode = "record" // Fetch from the API and save every page
+	CassetteReplay CassetteMode = "replay" // Serve saved pages, never touch the network
+)
+
+// ErrNotRecorded is returned in replay mode for a request the cassette doesn't hold.
+var ErrNotRecorded = errors.New("request not recorded in cassette")
+
+// cassette saves Tables API pages to a directory, one file per request, keyed by
+// table, params and cursor. The API key is never part of the key or the file.
+type cassette struct {
+	dir  string
+	mode CassetteMode
+}
+
+// cassetteRequest identifies a recorded page. Saved alongside the page so a
+// recording can be read without recomputing keys.
+type cassetteRequest struct {
+	Table      string            `json:"table"`
+	Params     map[string]string `json:"params"`
+	Cursor     string            `json:"cursor,omitempty"`
+	RecordedAt time.Time         `json:"recorded_at"`
+}
+
+// cassetteFile is a recorded page: the API response with the request added. The
+// extra key is skipped by decodePage, so a file reads back like a live response.
+type cassetteFile struct {
+	Request cassetteRequest `json:"request"`
+	*Response
+}
+
+// WithCassette records every page fetched to dir, or replays pages from dir
+// without any network access.
+func WithCassette(dir string, mode CassetteMode) Option {
+	return func(c *Client) {
+		c.cassette = &cassette{dir: dir, mode: mode}
+	}
+}
+
+// ParseCassetteMode validates a mode from configuration.
+func ParseCassetteMode(s string) (CassetteMode, error) {
+	switch mode := CassetteMode(strings.ToLower(s)); mode {
+	case CassetteRecord, CassetteReplay:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown cassette mode %q, expected record or replay", s)
+}
+
+// request splits a page URL into its cassette request, dropping the API key.
+func (cs *cassette) request(table string, u *url.URL) cassetteRequest {
+	req := cassetteRequest{Table: table, Params: make(map[string]string)}
+	for k, v := range u.Query() {
+		switch k {
+		case "api_key":
+		case "qopts.cursor_id":
+			req.Cursor = v[0]
+		default:
+			req.Params[k] = v[0]
+		}
+	}
+	return req
+}
+
+// path returns the file of a request: the table name followed by a hash of the
+// sorted params and cursor, e.g. SHARADAR_SF1-3f2a9c0d1e4b5a67.json.
+func (cs *cassette) path(req cassetteRequest) string {
+	keys := make([]string, 0, len(req.Params))
+	for k := range req.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, req.Params[k])
+	}
+	fmt.Fprintf(h, "cursor=%s\n", req.Cursor)
+
+	name := strings.ReplaceAll(req.Table, "/", "_") + "-" + hex.EncodeToString(h.Sum(nil))[:16] + ".json"
+	return filepath.Join(cs.dir, name)
+}
+
+// load replays the page recorded for a request.
+func (cs *cassette) load(table string, u *url.URL) (*Response, error) {
+	req := cs.request(table, u)
+	path := cs.path(req)
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %v cursor %q (%s)", ErrNotRecorded, table, req.Params, req.Cursor, filepath.Base(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening cassette: %w", err)
+	}
+	defer f.Close()
+
+	resp, err := decodePage(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %w", filepath.Base(path), err)
+	}
+	return resp, nil
+}
+
+// save records the page fetched for a request. The file is written under a
+// temporary name and renamed, so a cancelled run never leaves half a page.
+func (cs *cassette) save(table string, u *url.URL, resp *Response) error {
+	req := cs.request(table, u)
+	req.RecordedAt = time.Now().UTC()
+	path := cs.path(req)
+
+	if err := os.MkdirAll(cs.dir, 0o755); err != nil {
+		return fmt.Errorf("creating cassette dir: %w", err)
+	}
+
+	raw, err := json.Marshal(cassetteFile{Request: req, Response: resp})
+	if err != nil {
+		return fmt.Errorf("encoding cassette: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
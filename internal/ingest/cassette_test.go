@@ -0,0 +1,90 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pagedServer serves SHARADAR/DAILY as two pages linked by a cursor.
+func pagedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		columns := `"columns":[{"name":"ticker"},{"name":"date"},{"name":"marketcap"}]`
+		if r.URL.Query().Get("qopts.cursor_id") == "" {
+			fmt.Fprintf(w, `{"datatable":{"data":[["AAPL","2024-06-27",3283.1]],%s},"meta":{"next_cursor_id":"page2"}}`, columns)
+			return
+		}
+		fmt.Fprintf(w, `{"datatable":{"data":[["AAPL","2024-06-28",3293.2]],%s},"meta":{"next_cursor_id":null}}`, columns)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestCassetteRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	srv := pagedServer(t)
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	recorder := NewClient("secret-key", WithBaseURL(srv.URL), WithCassette(dir, CassetteRecord))
+	recorded, err := recorder.fetchAll(context.Background(), since)
+	if err != nil {
+		t.Fatalf("recording: %v", err)
+	}
+	if len(recorded) != 2 {
+		t.Fatalf("recorded rows = %d, want 2", len(recorded))
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "SHARADAR_DAILY-*.json"))
+	if len(files) != 2 {
+		t.Fatalf("cassette files = %d, want one per page", len(files))
+	}
+	for _, f := range files {
+		raw, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(raw), "secret-key") {
+			t.Errorf("%s contains the API key", filepath.Base(f))
+		}
+	}
+
+	// Replay with the server gone and no key
+	srv.Close()
+	replayer := NewClient("", WithBaseURL(srv.URL), WithCassette(dir, CassetteReplay))
+	replayed, err := replayer.fetchAll(context.Background(), since)
+	if err != nil {
+		t.Fatalf("replaying: %v", err)
+	}
+	if len(replayed) != 2 || !replayed[1].Date.Equal(recorded[1].Date) || replayed[1].MarketCap.String() != "3293.2" {
+		t.Errorf("replayed rows = %+v, want the recorded rows", replayed)
+	}
+	if calls := replayer.Usage(context.Background()).Calls; calls != 0 {
+		t.Errorf("replay used %d API calls, want 0", calls)
+	}
+
+	// Another watermark is another request
+	_, err = replayer.fetchAll(context.Background(), since.AddDate(0, 0, 1))
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("err = %v, want ErrNotRecorded", err)
+	}
+}
+
+// fetchAll fetches every SHARADAR/DAILY page for AAPL from since.
+func (c *Client) fetchAll(ctx context.Context, since time.Time) ([]DailyRow, error) {
+	var all []DailyRow
+	err := c.fetchDailyBatch(ctx, []string{"AAPL"}, since, func(rows []DailyRow) error {
+		all = append(all, rows...)
+		return nil
+	})
+	return all, err
+}
@@ -2,7 +2,7 @@ package ingest
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -14,89 +14,170 @@ import (
 )
 
 const (
-	baseURL        = "https://data.nasdaq.com/api/v3/datatables"
+	defaultBaseURL = "https://data.nasdaq.com/api/v3/datatables"
 	defaultTimeout = 60 * time.Second
-	rateLimit      = 2 // requests per second (conservative for authenticated users)
+	rateLimit      = 2    // requests per second (conservative for authenticated users)
+	maxErrorBody   = 4096 // Bytes of an error response kept for the error message
+	maxAttempts    = 3    // Tries per page for errors other than 429
+	maxThrottles   = 5    // 429 responses tolerated per page
 )
 
 // Client is a rate-limited client for Nasdaq Data Link Tables API.
 type Client struct {
 	apiKey     string
+	baseURL    string
 	httpClient *http.Client
 	limiter    *rateLimiter
-}
+	usage      *usageTracker
+	cassette   *cassette // Records or replays pages when set
+
+	strictSchema bool // Refuse pages missing a required column
 
-// rateLimiter implements a simple token bucket rate limiter.
-type rateLimiter struct {
-	mu       sync.Mutex
-	lastCall time.Time
-	interval time.Duration
+	exportPollInterval time.Duration
+	exportMaxWait      time.Duration
 }
 
-func newRateLimiter(requestsPerSecond int) *rateLimiter {
-	return &rateLimiter{
-		interval: time.Second / time.Duration(requestsPerSecond),
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL points the client at another Tables API root, such as a local stand-in.
+func WithBaseURL(u string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(u, "/")
 	}
 }
 
-func (r *rateLimiter) Wait() {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	now := time.Now()
-	elapsed := now.Sub(r.lastCall)
-	if elapsed < r.interval {
-		time.Sleep(r.interval - elapsed)
+// WithExportPollInterval sets how often a bulk export's status is checked.
+func WithExportPollInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.exportPollInterval = d
 	}
-	r.lastCall = time.Now()
 }
 
 // NewClient creates a new Sharadar API client.
-func NewClient(apiKey string) *Client {
-	return &Client{
-		apiKey: apiKey,
+func NewClient(apiKey string, opts ...Option) *Client {
+	c := &Client{
+		apiKey:  apiKey,
+		baseURL: defaultBaseURL,
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
-		limiter: newRateLimiter(rateLimit),
+		limiter:            newRateLimiter(rateLimit),
+		usage:              &usageTracker{callQuota: DefaultCallQuota},
+		exportPollInterval: defaultExportPollInterval,
+		exportMaxWait:      defaultExportMaxWait,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
 // FetchTable fetches data from a table with the given parameters.
-// Handles pagination automatically and returns all rows.
+// Handles pagination automatically and returns all rows, so it is meant for small
+// tables; use ForEachPage for anything that can run to millions of rows.
 func (c *Client) FetchTable(ctx context.Context, table string, params map[string]string) (*Response, error) {
 	allData := &Response{}
-	var cursorID *string
-
-	for {
-		resp, err := c.fetchPage(ctx, table, params, cursorID)
-		if err != nil {
-			return nil, err
-		}
 
+	err := c.ForEachPage(ctx, table, params, func(page *Response) error {
 		// Merge columns (only needed on first page)
 		if len(allData.Datatable.Columns) == 0 {
-			allData.Datatable.Columns = resp.Datatable.Columns
+			allData.Datatable.Columns = page.Datatable.Columns
 		}
 
 		// Append data
-		allData.Datatable.Data = append(allData.Datatable.Data, resp.Datatable.Data...)
+		allData.Datatable.Data = append(allData.Datatable.Data, page.Datatable.Data...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return allData, nil
+}
+
+// ForEachPage fetches a table page by page, calling fn with each page as soon as
+// it is decoded. The next page downloads while fn runs, so parsing and upserting
+// overlap with the network and only about two pages are in memory at a time.
+// Stops at the first fetch error or error returned by fn. The columns are checked
+// against the table's schema first, see CheckSchema.
+func (c *Client) ForEachPage(ctx context.Context, table string, params map[string]string, fn func(page *Response) error) error {
+	type result struct {
+		page *Response
+		err  error
+	}
+
+	// Cancelled on return so the fetcher stops if fn fails
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Unbuffered: the fetcher downloads one page ahead, then waits for fn
+	pages := make(chan result)
+
+	go func() {
+		defer close(pages)
+
+		var cursorID *string
+		var columns []Column
+		for {
+			resp, err := c.fetchPage(fetchCtx, table, params, cursorID)
+			if err == nil {
+				// Columns are repeated on every page, but carry them forward in case one omits them
+				if len(resp.Datatable.Columns) == 0 {
+					resp.Datatable.Columns = columns
+				}
+				columns = resp.Datatable.Columns
+			}
+
+			select {
+			case pages <- result{page: resp, err: err}:
+			case <-fetchCtx.Done():
+				return
+			}
 
-		// Check for more pages
-		if resp.Meta.NextCursorID == nil || *resp.Meta.NextCursorID == "" {
-			break
+			// Check for more pages
+			if err != nil || resp.Meta.NextCursorID == nil || *resp.Meta.NextCursorID == "" {
+				return
+			}
+			cursorID = resp.Meta.NextCursorID
+			log.Printf("Fetching next page (cursor: %s...)", (*cursorID)[:min(20, len(*cursorID))])
+		}
+	}()
+
+	checked := false
+	for r := range pages {
+		if r.err != nil {
+			return r.err
+		}
+		// Every page has the same columns, so the first one is checked
+		if !checked {
+			if err := c.checkSchema(ctx, table, r.page.Datatable.Columns); err != nil {
+				return err
+			}
+			checked = true
+		}
+		if err := fn(r.page); err != nil {
+			return err
 		}
-		cursorID = resp.Meta.NextCursorID
-		log.Printf("Fetching next page (cursor: %s...)", (*cursorID)[:min(20, len(*cursorID))])
 	}
 
-	return allData, nil
+	// The fetcher exits without sending when ctx is cancelled
+	return ctx.Err()
 }
 
-// fetchPage fetches a single page of data.
-func (c *Client) fetchPage(ctx context.Context, table string, params map[string]string, cursorID *string) (*Response, error) {
-	// Build URL
-	u, err := url.Parse(fmt.Sprintf("%s/%s.json", baseURL, table))
+// ForEachQueryPage is ForEachPage for a built query, which is validated before
+// any request is made.
+func (c *Client) ForEachQueryPage(ctx context.Context, table string, q *Query, fn func(page *Response) error) error {
+	params, err := q.Params()
+	if err != nil {
+		return err
+	}
+	return c.ForEachPage(ctx, table, params, fn)
+}
+
+// tableURL builds the request URL for a table with the API key and params.
+func (c *Client) tableURL(table string, params map[string]string) (*url.URL, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/%s.json", c.baseURL, table))
 	if err != nil {
 		return nil, fmt.Errorf("invalid table name: %w", err)
 	}
@@ -106,40 +187,103 @@ func (c *Client) fetchPage(ctx context.Context, table string, params map[string]
 	for k, v := range params {
 		q.Set(k, v)
 	}
+	u.RawQuery = q.Encode()
+
+	return u, nil
+}
+
+// fetchPage fetches a single page of data.
+func (c *Client) fetchPage(ctx context.Context, table string, params map[string]string, cursorID *string) (*Response, error) {
+	// Build URL
+	u, err := c.tableURL(table, params)
+	if err != nil {
+		return nil, err
+	}
 	if cursorID != nil {
+		q := u.Query()
 		q.Set("qopts.cursor_id", *cursorID)
+		u.RawQuery = q.Encode()
 	}
-	u.RawQuery = q.Encode()
 
-	// Rate limit
-	c.limiter.Wait()
+	if c.cassette != nil && c.cassette.mode == CassetteReplay {
+		return c.cassette.load(table, u)
+	}
 
-	// Make request with retries
+	// Make request with retries. A 429 pauses the limiter for every fetcher
+	// instead of backing off here, and doesn't use up an attempt.
 	var resp *Response
 	var lastErr error
-	for attempt := 0; attempt < 3; attempt++ {
-		if attempt > 0 {
-			backoff := time.Duration(1<<attempt) * time.Second
-			log.Printf("Retry attempt %d after %v", attempt, backoff)
-			time.Sleep(backoff)
+	var backoff time.Duration
+	failures, throttles := 0, 0
+	for failures < maxAttempts && throttles <= maxThrottles {
+		if backoff > 0 {
+			log.Printf("Retry attempt %d after %v", failures, backoff)
+			if err := sleepCtx(ctx, backoff); err != nil {
+				return nil, err
+			}
 		}
 
-		resp, lastErr = c.doRequest(ctx, u.String())
+		resp, lastErr = c.call(ctx, u.String())
 		if lastErr == nil {
+			if c.cassette != nil {
+				if err := c.cassette.save(table, u, resp); err != nil {
+					return nil, err
+				}
+			}
 			return resp, nil
 		}
 
-		// Don't retry on context cancellation
+		// Don't retry on context cancellation or once the quota is used up
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
+		if errors.Is(lastErr, ErrQuotaExceeded) {
+			return nil, lastErr
+		}
+
+		var rateErr *RateLimitError
+		if errors.As(lastErr, &rateErr) {
+			throttles++
+			pause := c.limiter.Throttle(rateErr.RetryAfter)
+			c.usage.addThrottled(ctx)
+			log.Printf("Rate limited, pausing requests for %v", pause)
+			backoff = 0
+			continue
+		}
 
-		log.Printf("Request failed (attempt %d): %v", attempt+1, lastErr)
+		failures++
+		backoff = time.Duration(1<<failures) * time.Second
+		log.Printf("Request failed (attempt %d): %v", failures, lastErr)
 	}
 
 	return nil, fmt.Errorf("all retries failed: %w", lastErr)
 }
 
+// call makes one API request within the quota and rate limit.
+func (c *Client) call(ctx context.Context, urlStr string) (*Response, error) {
+	// Counted once the slot comes, so a call cancelled while waiting isn't counted
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	if err := c.usage.reserve(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	c.limiter.Success()
+	c.usage.addRows(ctx, len(resp.Datatable.Data))
+	return resp, nil
+}
+
+// Usage returns today's API usage against the configured quotas.
+func (c *Client) Usage(ctx context.Context) Usage {
+	return c.usage.Usage(ctx)
+}
+
 func (c *Client) doRequest(ctx context.Context, urlStr string) (*Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
 	if err != nil {
@@ -152,36 +296,30 @@ func (c *Client) doRequest(ctx context.Context, urlStr string) (*Response, error
 	}
 	defer httpResp.Body.Close()
 
-	body, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("reading response: %w", err)
-	}
-
 	if httpResp.StatusCode == http.StatusTooManyRequests {
-		return nil, fmt.Errorf("rate limited (429)")
+		return nil, &RateLimitError{RetryAfter: parseRetryAfter(httpResp.Header)}
 	}
 
 	if httpResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxErrorBody))
 		return nil, fmt.Errorf("unexpected status %d: %s", httpResp.StatusCode, string(body))
 	}
 
-	var resp Response
-	if err := json.Unmarshal(body, &resp); err != nil {
+	// Decode straight from the body rather than reading it into memory first
+	resp, err := decodePage(httpResp.Body)
+	if err != nil {
 		return nil, fmt.Errorf("parsing response: %w", err)
 	}
 
-	return &resp, nil
+	return resp, nil
 }
 
 // FetchTickers fetches tickers from SHARADAR/TICKERS for SF1 table.
 // If tickers slice is empty, fetches all tickers.
 func (c *Client) FetchTickers(ctx context.Context, tickers []string) ([]TickerRow, error) {
-	params := map[string]string{
-		"table": "SF1",
-	}
-
-	if len(tickers) > 0 {
-		params["ticker"] = strings.Join(tickers, ",")
+	params, err := NewQuery().Columns(TickerSchema.Names()...).Eq("table", "SF1").In("ticker", tickers...).Params()
+	if err != nil {
+		return nil, err
 	}
 
 	resp, err := c.FetchTable(ctx, "SHARADAR/TICKERS", params)
@@ -192,29 +330,54 @@ func (c *Client) FetchTickers(ctx context.Context, tickers []string) ([]TickerRo
 	return ParseTickers(resp)
 }
 
-// SF1Batch represents a batch of SF1 rows from the API.
-type SF1Batch struct {
-	Rows  []SF1Row
+// Batch is a page of rows from the API, or the error that ended a fetch.
+type Batch[T any] struct {
+	Rows  []T
 	Error error
 }
 
+// Batches of each table's rows.
+type (
+	SF1Batch   = Batch[SF1Row]
+	DailyBatch = Batch[DailyRow]
+	PriceBatch = Batch[PriceRow] // SEP or SFP
+)
+
 const apiBatchSize = 100 // Tickers per API request to avoid 414 errors
 
-// FetchSF1Stream fetches SF1 data with parallel API requests.
-// Uses up to maxParallel concurrent fetchers, streaming results to channel.
-func (c *Client) FetchSF1Stream(ctx context.Context, tickers []string, dimension string, since time.Time, maxParallel int) <-chan SF1Batch {
-	ch := make(chan SF1Batch, maxParallel)
+// FetchSF1Stream fetches SF1 data of one dimension with parallel API requests,
+// see fetchStream.
+func (c *Client) FetchSF1Stream(ctx context.Context, groups []TickerGroup, dimension string, maxParallel int) <-chan SF1Batch {
+	return fetchStream(ctx, groups, maxParallel, strings.TrimSpace("SF1 "+dimension), func(chunk TickerGroup, emit func([]SF1Row) error) error {
+		return c.fetchSF1Batch(ctx, chunk.Tickers, dimension, chunk.Since, emit)
+	})
+}
+
+// fetchStream fans a fetch out over ticker groups. Each group is fetched from its
+// own watermark, split into requests of up to apiBatchSize tickers. Uses up to
+// maxParallel concurrent fetchers, streaming results to the channel one API page
+// at a time. fetch fetches one chunk, passing each parsed page to emit; name
+// describes the fetch in log messages.
+func fetchStream[T any](ctx context.Context, groups []TickerGroup, maxParallel int, name string, fetch func(chunk TickerGroup, emit func([]T) error) error) <-chan Batch[T] {
+	ch := make(chan Batch[T], maxParallel)
+
+	send := func(chunk TickerGroup) {
+		sendBatches(ctx, ch, func(emit func([]T) error) error {
+			return fetch(chunk, emit)
+		})
+	}
 
 	go func() {
 		defer close(ch)
 
+		chunks := chunkGroups(groups)
+		if len(chunks) == 0 {
+			return
+		}
+
 		// If small batch, send directly
-		if len(tickers) <= apiBatchSize {
-			rows, err := c.fetchSF1Batch(ctx, tickers, dimension, since)
-			select {
-			case ch <- SF1Batch{Rows: rows, Error: err}:
-			case <-ctx.Done():
-			}
+		if len(chunks) == 1 {
+			send(chunks[0])
 			return
 		}
 
@@ -222,33 +385,21 @@ func (c *Client) FetchSF1Stream(ctx context.Context, tickers []string, dimension
 		var wg sync.WaitGroup
 		sem := make(chan struct{}, maxParallel)
 
-		for i := 0; i < len(tickers); i += apiBatchSize {
+		for i, chunk := range chunks {
 			if ctx.Err() != nil {
 				break
 			}
 
-			end := i + apiBatchSize
-			if end > len(tickers) {
-				end = len(tickers)
-			}
-			batch := tickers[i:end]
-			batchNum := i/apiBatchSize + 1
-
 			sem <- struct{}{} // Acquire slot
 			wg.Add(1)
 
-			go func(batch []string, num int) {
+			go func(chunk TickerGroup, num int) {
 				defer wg.Done()
 				defer func() { <-sem }() // Release slot
 
-				log.Printf("Fetching SF1 batch %d (%d tickers, dimension: %s)", num, len(batch), dimension)
-				rows, err := c.fetchSF1Batch(ctx, batch, dimension, since)
-
-				select {
-				case ch <- SF1Batch{Rows: rows, Error: err}:
-				case <-ctx.Done():
-				}
-			}(batch, batchNum)
+				log.Printf("Fetching %s batch %d/%d (%d tickers, since: %s)", name, num, len(chunks), len(chunk.Tickers), formatSince(chunk.Since))
+				send(chunk)
+			}(chunk, i+1)
 		}
 
 		wg.Wait()
@@ -257,128 +408,141 @@ func (c *Client) FetchSF1Stream(ctx context.Context, tickers []string, dimension
 	return ch
 }
 
-// fetchSF1Batch fetches a single batch of SF1 data.
-func (c *Client) fetchSF1Batch(ctx context.Context, tickers []string, dimension string, since time.Time) ([]SF1Row, error) {
-	params := make(map[string]string)
-
-	if len(tickers) > 0 {
-		params["ticker"] = strings.Join(tickers, ",")
-	}
-
-	if dimension != "" {
-		params["dimension"] = dimension
-	}
-
-	if !since.IsZero() {
-		params["lastupdated.gte"] = since.Format("2006-01-02")
-	}
-
-	resp, err := c.FetchTable(ctx, "SHARADAR/SF1", params)
+// sendBatches runs fetch, passing each page it emits on to ch as its own batch,
+// then any error. Sending stops once ctx is cancelled.
+func sendBatches[T any](ctx context.Context, ch chan<- Batch[T], fetch func(emit func([]T) error) error) {
+	err := fetch(func(rows []T) error {
+		select {
+		case ch <- Batch[T]{Rows: rows}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
 	if err != nil {
-		return nil, fmt.Errorf("fetching SF1: %w", err)
+		select {
+		case ch <- Batch[T]{Error: err}:
+		case <-ctx.Done():
+		}
 	}
-
-	return ParseSF1(resp)
 }
 
-// DailyBatch represents a batch of daily rows from the API.
-type DailyBatch struct {
-	Rows  []DailyRow
-	Error error
-}
-
-// FetchDaily fetches daily prices from SHARADAR/DAILY for a small set of tickers.
-func (c *Client) FetchDaily(ctx context.Context, tickers []string, since time.Time) ([]DailyRow, error) {
-	if len(tickers) == 0 {
-		return nil, fmt.Errorf("at least one ticker required for daily fetch")
+// chunkGroups splits ticker groups into requests of at most apiBatchSize tickers
+// to avoid 414 errors. Empty groups are dropped.
+func chunkGroups(groups []TickerGroup) []TickerGroup {
+	var chunks []TickerGroup
+	for _, g := range groups {
+		for i := 0; i < len(g.Tickers); i += apiBatchSize {
+			end := i + apiBatchSize
+			if end > len(g.Tickers) {
+				end = len(g.Tickers)
+			}
+			chunks = append(chunks, TickerGroup{Tickers: g.Tickers[i:end], Since: g.Since})
+		}
 	}
-
-	return c.fetchDailyBatch(ctx, tickers, since)
+	return chunks
 }
 
-// fetchDailyBatch fetches a single batch of daily data.
-func (c *Client) fetchDailyBatch(ctx context.Context, tickers []string, since time.Time) ([]DailyRow, error) {
-	params := map[string]string{
-		"ticker": strings.Join(tickers, ","),
+// formatSince renders a watermark for log messages.
+func formatSince(since time.Time) string {
+	if since.IsZero() {
+		return "all history"
 	}
+	return since.Format("2006-01-02")
+}
 
-	if !since.IsZero() {
-		params["date.gte"] = since.Format("2006-01-02")
+// fetchSF1Batch fetches a single batch of SF1 data, passing each parsed page to emit.
+func (c *Client) fetchSF1Batch(ctx context.Context, tickers []string, dimension string, since time.Time, emit func([]SF1Row) error) error {
+	q := NewQuery().Columns(SF1Schema.Names()...).In("ticker", tickers...).Gte("lastupdated", since)
+	if dimension != "" {
+		q.Eq("dimension", dimension)
 	}
 
-	resp, err := c.FetchTable(ctx, "SHARADAR/DAILY", params)
+	err := c.ForEachQueryPage(ctx, "SHARADAR/SF1", q, func(page *Response) error {
+		rows, err := ParseSF1(page)
+		if err != nil {
+			return err
+		}
+		return emit(rows)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("fetching daily: %w", err)
+		return fmt.Errorf("fetching SF1: %w", err)
 	}
 
-	return ParseDaily(resp)
+	return nil
 }
 
-// FetchDailyStream fetches daily data with parallel API requests.
-// Uses up to maxParallel concurrent fetchers, streaming results to channel.
-func (c *Client) FetchDailyStream(ctx context.Context, tickers []string, since time.Time, maxParallel int) <-chan DailyBatch {
-	ch := make(chan DailyBatch, maxParallel)
-
-	go func() {
-		defer close(ch)
-
-		if len(tickers) == 0 {
-			return
-		}
+// fetchDailyBatch fetches a single batch of daily data, passing each parsed page to emit.
+func (c *Client) fetchDailyBatch(ctx context.Context, tickers []string, since time.Time, emit func([]DailyRow) error) error {
+	q := NewQuery().Columns(DailySchema.Names()...).In("ticker", tickers...).Gte("date", since)
 
-		// Small batch - fetch directly
-		if len(tickers) <= apiBatchSize {
-			rows, err := c.fetchDailyBatch(ctx, tickers, since)
-			select {
-			case ch <- DailyBatch{Rows: rows, Error: err}:
-			case <-ctx.Done():
-			}
-			return
+	err := c.ForEachQueryPage(ctx, "SHARADAR/DAILY", q, func(page *Response) error {
+		rows, err := ParseDaily(page)
+		if err != nil {
+			return err
 		}
+		return emit(rows)
+	})
+	if err != nil {
+		return fmt.Errorf("fetching daily: %w", err)
+	}
 
-		// Parallel fetch with semaphore
-		var wg sync.WaitGroup
-		sem := make(chan struct{}, maxParallel)
-
-		for i := 0; i < len(tickers); i += apiBatchSize {
-			if ctx.Err() != nil {
-				break
-			}
+	return nil
+}
 
-			end := i + apiBatchSize
-			if end > len(tickers) {
-				end = len(tickers)
-			}
-			batch := tickers[i:end]
-			batchNum := i/apiBatchSize + 1
+// FetchDailyStream fetches daily data with parallel API requests, see fetchStream.
+func (c *Client) FetchDailyStream(ctx context.Context, groups []TickerGroup, maxParallel int) <-chan DailyBatch {
+	return fetchStream(ctx, groups, maxParallel, "daily", func(chunk TickerGroup, emit func([]DailyRow) error) error {
+		return c.fetchDailyBatch(ctx, chunk.Tickers, chunk.Since, emit)
+	})
+}
 
-			sem <- struct{}{} // Acquire slot
-			wg.Add(1)
+// FetchSFP fetches fund prices from SHARADAR/SFP for a small set of tickers, such
+// as the benchmark ETFs.
+func (c *Client) FetchSFP(ctx context.Context, tickers []string, since time.Time) ([]PriceRow, error) {
+	if len(tickers) == 0 {
+		return nil, fmt.Errorf("at least one ticker required for SFP fetch")
+	}
 
-			go func(batch []string, num int) {
-				defer wg.Done()
-				defer func() { <-sem }() // Release slot
+	var all []PriceRow
+	err := c.fetchPriceBatch(ctx, "SHARADAR/SFP", tickers, since, func(rows []PriceRow) error {
+		all = append(all, rows...)
+		return nil
+	})
+	return all, err
+}
 
-				log.Printf("Fetching daily batch %d (%d tickers)", num, len(batch))
-				rows, err := c.fetchDailyBatch(ctx, batch, since)
+// fetchPriceBatch fetches a single batch of SEP or SFP prices, passing each parsed page to emit.
+func (c *Client) fetchPriceBatch(ctx context.Context, table string, tickers []string, since time.Time, emit func([]PriceRow) error) error {
+	q := NewQuery().Columns(PriceSchema.Names()...).In("ticker", tickers...).Gte("date", since)
 
-				select {
-				case ch <- DailyBatch{Rows: rows, Error: err}:
-				case <-ctx.Done():
-				}
-			}(batch, batchNum)
+	err := c.ForEachQueryPage(ctx, table, q, func(page *Response) error {
+		rows, err := ParsePrices(page)
+		if err != nil {
+			return err
 		}
+		return emit(rows)
+	})
+	if err != nil {
+		return fmt.Errorf("fetching %s: %w", table, err)
+	}
 
-		wg.Wait()
-	}()
+	return nil
+}
 
-	return ch
+// FetchSEPStream fetches equity prices from SHARADAR/SEP with parallel API
+// requests, see fetchStream.
+func (c *Client) FetchSEPStream(ctx context.Context, groups []TickerGroup, maxParallel int) <-chan PriceBatch {
+	return fetchStream(ctx, groups, maxParallel, "SEP", func(chunk TickerGroup, emit func([]PriceRow) error) error {
+		return c.fetchPriceBatch(ctx, "SHARADAR/SEP", chunk.Tickers, chunk.Since, emit)
+	})
 }
 
 // FetchSP500Current fetches current S&P 500 constituents.
 func (c *Client) FetchSP500Current(ctx context.Context) ([]string, error) {
-	params := map[string]string{
-		"action": "current",
+	params, err := NewQuery().Eq("action", "current").Params()
+	if err != nil {
+		return nil, err
 	}
 
 	resp, err := c.FetchTable(ctx, "SHARADAR/SP500", params)
@@ -399,4 +563,20 @@ func (c *Client) FetchSP500Current(ctx context.Context) ([]string, error) {
 	}
 
 	return tickers, nil
-}
\ No newline at end of file
+}
+
+// FetchSP500History fetches the full S&P 500 membership history, including the
+// "added" and "removed" events and the "current" constituents.
+func (c *Client) FetchSP500History(ctx context.Context) ([]SP500Row, error) {
+	params, err := NewQuery().Params()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.FetchTable(ctx, "SHARADAR/SP500", params)
+	if err != nil {
+		return nil, fmt.Errorf("fetching SP500 history: %w", err)
+	}
+
+	return ParseSP500(resp)
+}
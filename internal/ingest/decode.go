@@ -0,0 +1,94 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// decodePage decodes one Tables API page from r token by token. Rows are decoded
+// one at a time straight from the body, so a page is never held as raw bytes
+// alongside its decoded rows. Numbers are kept as json.Number so decimals parse
+// without a float64 round trip.
+func decodePage(r io.Reader) (*Response, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var resp Response
+	err := decodeObject(dec, func(key string) error {
+		switch key {
+		case "datatable":
+			return decodeObject(dec, func(key string) error {
+				switch key {
+				case "data":
+					return decodeRows(dec, &resp)
+				case "columns":
+					return dec.Decode(&resp.Datatable.Columns)
+				}
+				return skipValue(dec)
+			})
+		case "meta":
+			return dec.Decode(&resp.Meta)
+		}
+		return skipValue(dec)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// decodeObject reads a JSON object, calling field for each key with the decoder
+// positioned at its value. field must consume the value.
+func decodeObject(dec *json.Decoder, field func(key string) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("expected object key, got %v", tok)
+		}
+		if err := field(key); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// decodeRows reads the data array one row at a time.
+func decodeRows(dec *json.Decoder, resp *Response) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		var row []interface{}
+		if err := dec.Decode(&row); err != nil {
+			return fmt.Errorf("row %d: %w", len(resp.Datatable.Data), err)
+		}
+		resp.Datatable.Data = append(resp.Datatable.Data, row)
+	}
+	return expectDelim(dec, ']')
+}
+
+// skipValue consumes a value the caller doesn't need.
+func skipValue(dec *json.Decoder) error {
+	var discard json.RawMessage
+	return dec.Decode(&discard)
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
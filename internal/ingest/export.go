@@ -0,0 +1,396 @@
+package ingest
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultExportPollInterval = 10 * time.Second
+	defaultExportMaxWait      = time.Hour // Longest wait for an export file to be created
+	exportChunkRows           = 10000     // CSV rows per chunk, the same as an API page
+)
+
+// ErrExportNotReady is returned by exportStatus while the export file is being created.
+var ErrExportNotReady = errors.New("bulk export not ready")
+
+// errExportCassette is returned by Export when a cassette is configured, as a
+// cassette holds API pages and an export would bypass it.
+var errExportCassette = errors.New("bulk exports are not recorded in cassettes")
+
+// transientError marks an export status check that failed in a way worth
+// retrying: a network error or a 5xx response.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// exportResponse is the API response to a qopts.export=true request.
+type exportResponse struct {
+	DatatableBulkDownload struct {
+		File struct {
+			Link             *string `json:"link"`
+			Status           string  `json:"status"` // "fresh", "regenerating" or "creating"
+			DataSnapshotTime string  `json:"data_snapshot_time"`
+		} `json:"file"`
+	} `json:"datatable_bulk_download"`
+}
+
+// ExportSF1 downloads SHARADAR/SF1 as a bulk export and passes the parsed rows to
+// emit in chunks. Much faster than paging for full-history backfills. params
+// filter the export like a normal request, e.g. {"dimension": "ARQ"}.
+func (c *Client) ExportSF1(ctx context.Context, params map[string]string, emit func([]SF1Row) error) error {
+	err := c.Export(ctx, "SHARADAR/SF1", params, func(chunk *Response) error {
+		rows, err := ParseSF1(chunk)
+		if err != nil {
+			return err
+		}
+		return emit(rows)
+	})
+	if err != nil {
+		return fmt.Errorf("exporting SF1: %w", err)
+	}
+	return nil
+}
+
+// ExportDaily downloads SHARADAR/DAILY as a bulk export and passes the parsed rows
+// to emit in chunks.
+func (c *Client) ExportDaily(ctx context.Context, params map[string]string, emit func([]DailyRow) error) error {
+	err := c.Export(ctx, "SHARADAR/DAILY", params, func(chunk *Response) error {
+		rows, err := ParseDaily(chunk)
+		if err != nil {
+			return err
+		}
+		return emit(rows)
+	})
+	if err != nil {
+		return fmt.Errorf("exporting daily: %w", err)
+	}
+	return nil
+}
+
+// CanExport reports whether bulk exports are available: not while recording or
+// replaying a cassette, which holds API pages only.
+func (c *Client) CanExport() bool {
+	return c.cassette == nil
+}
+
+// ExportSF1Stream exports SHARADAR/SF1 of one dimension, every dimension when
+// empty, streaming each chunk as a batch like FetchSF1Stream.
+func (c *Client) ExportSF1Stream(ctx context.Context, dimension string) <-chan SF1Batch {
+	q := NewQuery().Columns(SF1Schema.Names()...)
+	if dimension != "" {
+		q.Eq("dimension", dimension)
+	}
+	return exportStream(ctx, func(emit func([]SF1Row) error) error {
+		params, err := q.Params()
+		if err != nil {
+			return err
+		}
+		return c.ExportSF1(ctx, params, emit)
+	})
+}
+
+// ExportDailyStream exports SHARADAR/DAILY, streaming each chunk as a batch like
+// FetchDailyStream.
+func (c *Client) ExportDailyStream(ctx context.Context) <-chan DailyBatch {
+	return exportStream(ctx, func(emit func([]DailyRow) error) error {
+		params, err := NewQuery().Columns(DailySchema.Names()...).Params()
+		if err != nil {
+			return err
+		}
+		return c.ExportDaily(ctx, params, emit)
+	})
+}
+
+// exportStream runs an export in the background, see sendBatches.
+func exportStream[T any](ctx context.Context, export func(emit func([]T) error) error) <-chan Batch[T] {
+	ch := make(chan Batch[T], 1)
+	go func() {
+		defer close(ch)
+		sendBatches(ctx, ch, export)
+	}()
+	return ch
+}
+
+// Export requests a bulk export of a table, polls until the file is ready,
+// downloads the zip and streams its CSV to fn in chunks of exportChunkRows. Each
+// chunk is a Response with the CSV header as its columns and string values, so
+// the usual parsers apply.
+func (c *Client) Export(ctx context.Context, table string, params map[string]string, fn func(chunk *Response) error) error {
+	if !c.CanExport() {
+		return errExportCassette
+	}
+
+	link, err := c.waitForExport(ctx, table, params)
+	if err != nil {
+		return err
+	}
+
+	// A zip is read from its end, so the download goes to a temporary file first
+	f, err := os.CreateTemp("", "sharadar-export-*.zip")
+	if err != nil {
+		return fmt.Errorf("creating export file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	size, err := c.download(ctx, link, f)
+	if err != nil {
+		return err
+	}
+	log.Printf("Downloaded %s export (%d bytes)", table, size)
+
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return fmt.Errorf("opening export zip: %w", err)
+	}
+
+	// Every chunk has the CSV header as its columns, so the first one is checked
+	checked := false
+	count, err := readZipCSV(ctx, zr, func(chunk *Response) error {
+		if !checked {
+			if err := c.checkSchema(ctx, table, chunk.Datatable.Columns); err != nil {
+				return err
+			}
+			checked = true
+		}
+		return fn(chunk)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Parsed %d rows from %s export", count, table)
+
+	return nil
+}
+
+// readZipCSV streams every CSV file in a zip to fn in chunks of exportChunkRows.
+func readZipCSV(ctx context.Context, zr *zip.Reader, fn func(chunk *Response) error) (int, error) {
+	total := 0
+	for _, zf := range zr.File {
+		if !strings.HasSuffix(strings.ToLower(zf.Name), ".csv") {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return total, fmt.Errorf("opening %s: %w", zf.Name, err)
+		}
+		count, err := readCSVChunks(ctx, rc, exportChunkRows, fn)
+		rc.Close()
+		total += count
+		if err != nil {
+			return total, fmt.Errorf("reading %s: %w", zf.Name, err)
+		}
+	}
+	return total, nil
+}
+
+// waitForExport requests the export and polls until its download link is ready.
+// The first request starts creating the file; repeating it reports the status.
+// Transient failures are retried with fetchPage's backoff, and the whole wait is
+// capped at exportMaxWait.
+func (c *Client) waitForExport(ctx context.Context, table string, params map[string]string) (string, error) {
+	exportParams := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		exportParams[k] = v
+	}
+	exportParams["qopts.export"] = "true"
+
+	u, err := c.tableURL(table, exportParams)
+	if err != nil {
+		return "", err
+	}
+
+	deadline := time.Now().Add(c.exportMaxWait)
+	failures := 0
+	for {
+		link, err := c.exportStatus(ctx, u.String())
+		wait := c.exportPollInterval
+		switch {
+		case err == nil:
+			return link, nil
+		case errors.Is(err, ErrExportNotReady):
+			failures = 0
+			log.Printf("Waiting for %s export (next check in %v)", table, wait)
+		default:
+			var transient *transientError
+			if !errors.As(err, &transient) || ctx.Err() != nil {
+				return "", err
+			}
+			failures++
+			if failures >= maxAttempts {
+				return "", fmt.Errorf("all retries failed: %w", err)
+			}
+			wait = time.Duration(1<<failures) * time.Second
+			log.Printf("Export status check failed (attempt %d): %v", failures, err)
+		}
+
+		if time.Now().Add(wait).After(deadline) {
+			return "", fmt.Errorf("%s export not ready after %v", table, c.exportMaxWait)
+		}
+		if err := sleepCtx(ctx, wait); err != nil {
+			return "", err
+		}
+	}
+}
+
+// exportStatus checks an export once, returning its link or ErrExportNotReady.
+// A 429 also reads as not ready, after pausing the limiter.
+func (c *Client) exportStatus(ctx context.Context, urlStr string) (string, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	if err := c.usage.reserve(ctx); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", &transientError{fmt.Errorf("executing request: %w", err)}
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode == http.StatusTooManyRequests {
+		// Wait it out like any other pending export
+		pause := c.limiter.Throttle(parseRetryAfter(httpResp.Header))
+		c.usage.addThrottled(ctx)
+		log.Printf("Rate limited, pausing requests for %v", pause)
+		return "", ErrExportNotReady
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxErrorBody))
+		err := fmt.Errorf("unexpected status %d: %s", httpResp.StatusCode, string(body))
+		if httpResp.StatusCode >= http.StatusInternalServerError {
+			return "", &transientError{err}
+		}
+		return "", err
+	}
+
+	c.limiter.Success()
+
+	var resp exportResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return "", fmt.Errorf("parsing export status: %w", err)
+	}
+
+	// While regenerating, the link still points at the previous complete snapshot
+	file := resp.DatatableBulkDownload.File
+	if file.Link == nil || *file.Link == "" || file.Status == "creating" {
+		return "", ErrExportNotReady
+	}
+
+	log.Printf("Export ready (status: %s, snapshot: %s)", file.Status, file.DataSnapshotTime)
+	return *file.Link, nil
+}
+
+// download writes the export file to w and returns its size. The link is
+// pre-signed, so no API key is sent, and the client timeout doesn't apply since
+// a full table can take minutes; ctx bounds the download instead.
+func (c *Client) download(ctx context.Context, link string, w io.Writer) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return 0, fmt.Errorf("creating download request: %w", err)
+	}
+
+	httpResp, err := (&http.Client{Transport: c.httpClient.Transport}).Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("downloading export: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxErrorBody))
+		return 0, fmt.Errorf("downloading export: unexpected status %d: %s", httpResp.StatusCode, string(body))
+	}
+
+	size, err := io.Copy(w, httpResp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("downloading export: %w", err)
+	}
+	return size, nil
+}
+
+// readCSVChunks reads a CSV with a header row and passes its records to fn as
+// Responses of up to chunkRows rows. Returns the number of rows read.
+func readCSVChunks(ctx context.Context, r io.Reader, chunkRows int, fn func(chunk *Response) error) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // Short rows read as missing trailing values
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading header: %w", err)
+	}
+
+	columns := make([]Column, len(header))
+	for i, name := range header {
+		columns[i] = Column{Name: strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))}
+	}
+
+	newChunk := func() *Response {
+		chunk := &Response{}
+		chunk.Datatable.Columns = columns
+		chunk.Datatable.Data = make([][]interface{}, 0, chunkRows)
+		return chunk
+	}
+
+	count := 0
+	chunk := newChunk()
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+
+		row := make([]interface{}, len(record))
+		for i, v := range record {
+			if v != "" {
+				row[i] = v // Empty fields stay nil, like JSON nulls
+			}
+		}
+		chunk.Datatable.Data = append(chunk.Datatable.Data, row)
+		count++
+
+		if len(chunk.Datatable.Data) == chunkRows {
+			if err := ctx.Err(); err != nil {
+				return count, err
+			}
+			if err := fn(chunk); err != nil {
+				return count, err
+			}
+			chunk = newChunk()
+		}
+	}
+
+	if len(chunk.Datatable.Data) > 0 {
+		if err := fn(chunk); err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,263 @@
+package ingest
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// zipCSV builds an export zip holding one CSV file.
+func zipCSV(t *testing.T, name, csv string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(csv)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// exportServer stands in for the Tables API: the export is reported as being
+// created for the first pendingPolls status checks, then links to the zip.
+func exportServer(t *testing.T, table string, pendingPolls int32, archive []byte) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var polls atomic.Int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + table + ".json":
+			if r.URL.Query().Get("qopts.export") != "true" {
+				http.Error(w, "expected qopts.export=true", http.StatusBadRequest)
+				return
+			}
+			if r.URL.Query().Get("api_key") != "test-key" {
+				http.Error(w, "bad api key", http.StatusForbidden)
+				return
+			}
+			if polls.Add(1) <= pendingPolls {
+				fmt.Fprint(w, `{"datatable_bulk_download":{"file":{"link":null,"status":"creating","data_snapshot_time":null}}}`)
+				return
+			}
+			fmt.Fprintf(w, `{"datatable_bulk_download":{"file":{"link":"%s/files/export.zip","status":"fresh","data_snapshot_time":"2024-06-28 22:04:11 UTC"}}}`, srv.URL)
+		case "/files/export.zip":
+			if r.URL.Query().Get("api_key") != "" {
+				http.Error(w, "download links must not carry the api key", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/zip")
+			w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &polls
+}
+
+func testClient(srv *httptest.Server) *Client {
+	return NewClient("test-key", WithBaseURL(srv.URL), WithExportPollInterval(time.Millisecond))
+}
+
+func TestExportSF1(t *testing.T) {
+	csv := "ticker,dimension,calendardate,datekey,reportperiod,lastupdated,revenue,netinc,pe,marketcap\n" +
+		"AAPL,ARQ,2024-03-31,2024-05-03,2024-03-30,2024-05-04,90753000000,23636000000,28.123456789,2600000000000\n" +
+		"MSFT,ARQ,2024-03-31,2024-04-26,2024-03-31,2024-04-27,61858000000,,35.5,\n" +
+		",ARQ,2024-03-31,2024-04-26,2024-03-31,2024-04-27,1,1,1,1\n" // No ticker, skipped by ParseSF1
+
+	srv, polls := exportServer(t, "SHARADAR/SF1", 2, zipCSV(t, "SHARADAR_SF1.csv", csv))
+
+	var rows []SF1Row
+	err := testClient(srv).ExportSF1(context.Background(), map[string]string{"dimension": "ARQ"}, func(chunk []SF1Row) error {
+		rows = append(rows, chunk...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportSF1: %v", err)
+	}
+
+	if got := polls.Load(); got != 3 {
+		t.Errorf("status checks = %d, want 3 (two while creating, one ready)", got)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+
+	aapl := rows[0]
+	if aapl.Ticker != "AAPL" || aapl.Dimension != "ARQ" {
+		t.Errorf("row 0 = %s/%s, want AAPL/ARQ", aapl.Ticker, aapl.Dimension)
+	}
+	if want := time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC); !aapl.DateKey.Equal(want) {
+		t.Errorf("DateKey = %v, want %v", aapl.DateKey, want)
+	}
+	if aapl.PE == nil || aapl.PE.String() != "28.123456789" {
+		t.Errorf("PE = %v, want 28.123456789 without float rounding", aapl.PE)
+	}
+
+	msft := rows[1]
+	if msft.NetIncome != nil || msft.MarketCap != nil {
+		t.Errorf("empty CSV fields should parse as nil, got NetIncome=%v MarketCap=%v", msft.NetIncome, msft.MarketCap)
+	}
+}
+
+func TestExportDailyChunks(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("ticker,date,lastupdated,ev,evebit,evebitda,marketcap,pb,pe,ps,open,high,low,close,volume,closeunadj,dividends\n")
+	total := exportChunkRows + 5 // Spills into a second chunk
+	for i := 0; i < total; i++ {
+		day := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i)
+		fmt.Fprintf(&b, "SPY,%s,2024-06-28,,,,,,,,100.5,101,99.25,100.75,1500000.0,100.75,0\n", day.Format("2006-01-02"))
+	}
+
+	srv, _ := exportServer(t, "SHARADAR/DAILY", 0, zipCSV(t, "SHARADAR_DAILY.csv", b.String()))
+
+	var chunks []int
+	var last DailyRow
+	err := testClient(srv).ExportDaily(context.Background(), map[string]string{"ticker": "SPY"}, func(rows []DailyRow) error {
+		chunks = append(chunks, len(rows))
+		last = rows[len(rows)-1]
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportDaily: %v", err)
+	}
+
+	if len(chunks) != 2 || chunks[0] != exportChunkRows || chunks[1] != 5 {
+		t.Errorf("chunks = %v, want [%d 5]", chunks, exportChunkRows)
+	}
+	if last.Volume == nil || *last.Volume != 1500000 {
+		t.Errorf("Volume = %v, want 1500000", last.Volume)
+	}
+	if last.Close == nil || last.Close.String() != "100.75" {
+		t.Errorf("Close = %v, want 100.75", last.Close)
+	}
+}
+
+func TestExportStopsOnEmitError(t *testing.T) {
+	csv := "ticker,date,close\nSPY,2024-01-02,470.5\n"
+	srv, _ := exportServer(t, "SHARADAR/DAILY", 0, zipCSV(t, "SHARADAR_DAILY.csv", csv))
+
+	errStop := errors.New("stop")
+	err := testClient(srv).ExportDaily(context.Background(), nil, func([]DailyRow) error {
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("err = %v, want the emit error", err)
+	}
+}
+
+func TestExportStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"quandl_error":{"code":"QEPx04","message":"forbidden"}}`, http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	err := testClient(srv).Export(context.Background(), "SHARADAR/SF1", nil, func(*Response) error {
+		t.Fatal("fn called for a failed export")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("err = %v, want a 403 status error", err)
+	}
+}
+
+func TestExportCancelledWhilePolling(t *testing.T) {
+	srv, _ := exportServer(t, "SHARADAR/SF1", 1<<30, nil) // Never ready
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := testClient(srv).Export(ctx, "SHARADAR/SF1", nil, func(*Response) error { return nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestExportChecksSchema(t *testing.T) {
+	csv := "ticker,date,close\nSPY,2024-01-02,470.5\n"
+	srv, _ := exportServer(t, "SHARADAR/DAILY", 0, zipCSV(t, "SHARADAR_DAILY.csv", csv))
+
+	var drifts []SchemaDrift
+	ctx := WithDriftReporter(context.Background(), func(d SchemaDrift) {
+		drifts = append(drifts, d)
+	})
+
+	// Reported once for the file, and strict mode stops before any rows are emitted
+	client := NewClient("test-key", WithBaseURL(srv.URL), WithExportPollInterval(time.Millisecond), WithStrictSchema())
+	err := client.ExportDaily(ctx, nil, func([]DailyRow) error {
+		t.Fatal("rows emitted despite missing columns")
+		return nil
+	})
+	if !errors.Is(err, ErrSchemaDrift) {
+		t.Errorf("err = %v, want ErrSchemaDrift", err)
+	}
+	if len(drifts) != 1 || !drifts[0].Broken || fmt.Sprint(drifts[0].Added) != "[close]" {
+		t.Errorf("drifts = %+v, want one with close added and columns missing", drifts)
+	}
+}
+
+func TestExportSkipsCassette(t *testing.T) {
+	srv, polls := exportServer(t, "SHARADAR/SF1", 0, nil)
+
+	client := NewClient("", WithBaseURL(srv.URL), WithCassette(t.TempDir(), CassetteReplay))
+	if client.CanExport() {
+		t.Error("CanExport with a cassette, want paging")
+	}
+	err := client.Export(context.Background(), "SHARADAR/SF1", nil, func(*Response) error { return nil })
+	if err == nil || polls.Load() != 0 {
+		t.Errorf("err = %v after %d requests, want an error before any", err, polls.Load())
+	}
+}
+
+func TestExportRetriesTransientStatus(t *testing.T) {
+	csv := "ticker,date,close\nSPY,2024-01-02,470.5\n"
+	export, _ := exportServer(t, "SHARADAR/DAILY", 0, zipCSV(t, "SHARADAR_DAILY.csv", csv))
+
+	// One 503 before the export server answers
+	var failed atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !failed.Swap(true) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		http.Redirect(w, r, export.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	var rows []DailyRow
+	err := testClient(srv).ExportDaily(context.Background(), nil, func(chunk []DailyRow) error {
+		rows = append(rows, chunk...)
+		return nil
+	})
+	if err != nil || len(rows) != 1 {
+		t.Errorf("got %d rows, err %v, want 1 row after a retry", len(rows), err)
+	}
+}
+
+func TestExportMaxWait(t *testing.T) {
+	srv, _ := exportServer(t, "SHARADAR/SF1", 1<<30, nil) // Never ready
+
+	client := testClient(srv)
+	client.exportMaxWait = 20 * time.Millisecond
+	err := client.Export(context.Background(), "SHARADAR/SF1", nil, func(*Response) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "not ready after") {
+		t.Errorf("err = %v, want the wait to give up", err)
+	}
+}
@@ -0,0 +1,73 @@
+package ingest
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileTables are the Sharadar tables that can be loaded from CSV files.
+var FileTables = []string{"TICKERS", "SF1", "DAILY", "SEP", "SFP", "SP500"}
+
+// ReadFile streams a Sharadar CSV or zipped CSV file, as downloaded from the
+// Nasdaq website or a bulk export, to fn in chunks. The CSV header stands in for
+// Response.Columns, so the chunks go through the same parsers as API pages.
+// Returns the number of rows read.
+func ReadFile(ctx context.Context, path string, fn func(chunk *Response) error) (int, error) {
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return 0, fmt.Errorf("opening %s: %w", path, err)
+		}
+		defer zr.Close()
+
+		return readZipCSV(ctx, &zr.Reader, fn)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	count, err := readCSVChunks(ctx, f, exportChunkRows, fn)
+	if err != nil {
+		return count, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return count, nil
+}
+
+// TableFromFilename guesses the table of a downloaded file from its name, e.g.
+// SHARADAR_SF1_2f9c1e.zip is SF1. Returns "" if no table matches.
+func TableFromFilename(path string) string {
+	name := strings.ToUpper(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	name = strings.TrimPrefix(name, "SHARADAR_")
+	name = strings.TrimPrefix(name, "SHARADAR-")
+
+	for _, table := range FileTables {
+		if name == table || strings.HasPrefix(name, table+"_") || strings.HasPrefix(name, table+"-") {
+			return table
+		}
+	}
+	return ""
+}
+
+// FilterRows keeps the rows of a chunk whose column equals value. Rows are kept
+// unchanged when the column is absent, e.g. a TICKERS file already limited to SF1.
+func FilterRows(resp *Response, column, value string) {
+	idx := buildColumnIndex(resp.Datatable.Columns)
+	if _, ok := idx[column]; !ok {
+		return
+	}
+
+	kept := resp.Datatable.Data[:0]
+	for _, row := range resp.Datatable.Data {
+		if getString(row, idx, column) == value {
+			kept = append(kept, row)
+		}
+	}
+	resp.Datatable.Data = kept
+}
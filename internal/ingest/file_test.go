@@ -0,0 +1,80 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile writes a fixture to a temporary directory and returns its path.
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadFile(t *testing.T) {
+	// Website downloads start with a byte order mark
+	csv := "\ufeffticker,date,open,high,low,close,volume,closeadj,closeunadj,lastupdated\n" +
+		"AAPL,2024-01-02,187.15,188.44,183.89,185.64,82488700,184.94,185.64,2024-01-02\n" +
+		"MSFT,2024-01-02,373.86,375.9,366.77,370.87,25258600,,370.87,2024-01-02\n"
+
+	for _, path := range []string{
+		writeFile(t, "SHARADAR_SEP.csv", []byte(csv)),
+		writeFile(t, "SHARADAR_SEP_2f9c1e.zip", zipCSV(t, "SHARADAR_SEP.csv", csv)),
+	} {
+		var rows []PriceRow
+		count, err := ReadFile(context.Background(), path, func(chunk *Response) error {
+			parsed, err := ParsePrices(chunk)
+			rows = append(rows, parsed...)
+			return err
+		})
+		name := filepath.Base(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if count != 2 || len(rows) != 2 {
+			t.Fatalf("%s: read %d rows, parsed %d, want 2", name, count, len(rows))
+		}
+		if rows[0].Ticker != "AAPL" || rows[0].Close.String() != "185.64" || rows[1].CloseAdj != nil {
+			t.Errorf("%s: rows = %+v", name, rows)
+		}
+		if table := TableFromFilename(path); table != "SEP" {
+			t.Errorf("%s: table = %q, want SEP", name, table)
+		}
+	}
+
+	if _, err := ReadFile(context.Background(), filepath.Join(t.TempDir(), "missing.csv"), nil); err == nil {
+		t.Error("missing file: expected an error")
+	}
+}
+
+func TestReadFileFilterRows(t *testing.T) {
+	// TICKERS files cover every Sharadar table
+	csv := "table,ticker,name,exchange,isdelisted\n" +
+		"SF1,AAPL,Apple Inc,NASDAQ,N\n" +
+		"SEP,AAPL,Apple Inc,NASDAQ,N\n" +
+		"SFP,SPY,SPDR S&P 500 ETF,NYSEARCA,N\n" +
+		"SF1,LEH,Lehman Brothers,NYSE,Y\n"
+	path := writeFile(t, "SHARADAR_TICKERS.csv", []byte(csv))
+
+	var rows []TickerRow
+	_, err := ReadFile(context.Background(), path, func(chunk *Response) error {
+		FilterRows(chunk, "table", "SF1")
+		parsed, err := ParseTickers(chunk)
+		rows = append(rows, parsed...)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 2 || rows[0].Ticker != "AAPL" || rows[1].Ticker != "LEH" || !rows[1].IsDelisted {
+		t.Errorf("rows = %+v, want AAPL and delisted LEH", rows)
+	}
+}
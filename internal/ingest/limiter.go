@@ -0,0 +1,148 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	minThrottlePause = 2 * time.Second  // First pause after a 429 without Retry-After
+	maxThrottlePause = 2 * time.Minute  // Cap for consecutive 429s
+	maxInterval      = 10 * time.Second // Slowest request spacing after repeated 429s
+)
+
+// rateLimiter spaces requests at a fixed interval. Each caller reserves the next
+// free slot under the lock and waits for it outside, so a cancelled context stops
+// waiting at once and doesn't hold up the callers behind it.
+//
+// A 429 pauses every caller and doubles the interval; successful requests bring
+// it back down to the base rate a step at a time.
+type rateLimiter struct {
+	mu          sync.Mutex
+	base        time.Duration
+	interval    time.Duration
+	next        time.Time     // Earliest start of the next request
+	pause       time.Duration // Last pause without Retry-After, doubled on consecutive 429s
+	pausedUntil time.Time     // End of the last 429's pause
+}
+
+func newRateLimiter(requestsPerSecond int) *rateLimiter {
+	interval := time.Second / time.Duration(requestsPerSecond)
+	return &rateLimiter{
+		base:     interval,
+		interval: interval,
+	}
+}
+
+// Wait blocks until the caller's slot or until ctx is done. A caller whose slot
+// falls inside a pause that began while it slept reserves a new slot after it.
+func (r *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		if err := sleepCtx(ctx, time.Until(r.reserve())); err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		paused := r.pausedUntil.After(time.Now())
+		r.mu.Unlock()
+		if !paused {
+			return nil
+		}
+	}
+}
+
+// reserve takes the next free slot and returns its start.
+func (r *rateLimiter) reserve() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	at := r.next
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	r.next = at.Add(r.interval)
+	return at
+}
+
+// Throttle records a 429 and holds back every caller for retryAfter, or for an
+// adaptive pause when the server didn't say. Returns the pause applied.
+func (r *rateLimiter) Throttle(retryAfter time.Duration) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interval = min(r.interval*2, maxInterval)
+
+	pause := retryAfter
+	if pause <= 0 {
+		r.pause = min(max(r.pause*2, minThrottlePause), maxThrottlePause)
+		pause = r.pause
+	}
+
+	until := time.Now().Add(pause)
+	if until.After(r.next) {
+		r.next = until
+	}
+	if until.After(r.pausedUntil) {
+		r.pausedUntil = until
+	}
+	return pause
+}
+
+// Success records a successful request, easing the interval back towards the base rate.
+func (r *rateLimiter) Success() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pause = 0
+	if r.interval > r.base {
+		r.interval = max(r.interval-r.base/2, r.base)
+	}
+}
+
+// sleepCtx sleeps for d or until ctx is done, whichever comes first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RateLimitError is returned for a 429 response.
+type RateLimitError struct {
+	RetryAfter time.Duration // Zero when the response had no usable Retry-After
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited (429), retry after %v", e.RetryAfter)
+	}
+	return "rate limited (429)"
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
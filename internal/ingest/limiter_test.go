@@ -0,0 +1,28 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitHonoursPauseAfterReserving(t *testing.T) {
+	r := newRateLimiter(10) // 100ms apart
+	if err := r.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second caller reserves its slot 100ms out, then a 429 pauses everyone
+	start := time.Now()
+	done := make(chan time.Duration)
+	go func() {
+		r.Wait(context.Background())
+		done <- time.Since(start)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	r.Throttle(300 * time.Millisecond)
+
+	if waited := <-done; waited < 300*time.Millisecond {
+		t.Errorf("waited %v, want at least the 300ms pause", waited)
+	}
+}
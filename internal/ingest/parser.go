@@ -1,8 +1,9 @@
 package ingest
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
+	"strconv"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -42,6 +43,9 @@ func getBool(row []interface{}, idx map[string]int, col string) bool {
 		return v == "Y" || v == "true" || v == "1"
 	case float64:
 		return v != 0
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f != 0
 	}
 	return false
 }
@@ -62,6 +66,12 @@ func getDecimal(row []interface{}, idx map[string]int, col string) *decimal.Deci
 			return nil
 		}
 		return &d
+	case json.Number:
+		d, err := decimal.NewFromString(v.String())
+		if err != nil {
+			return nil
+		}
+		return &d
 	}
 	return nil
 }
@@ -81,6 +91,22 @@ func getInt64(row []interface{}, idx map[string]int, col string) *int64 {
 	case int:
 		n := int64(v)
 		return &n
+	case string:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return &n
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			n := int64(f) // CSV exports may write "1500000.0"
+			return &n
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return &n
+		}
+		if f, err := v.Float64(); err == nil {
+			n := int64(f) // e.g. "1.5e6"
+			return &n
+		}
 	}
 	return nil
 }
@@ -121,6 +147,8 @@ func ParseTickers(resp *Response) ([]TickerRow, error) {
 			Industry:     getString(row, idx, "industry"),
 			ScaleRevenue: getString(row, idx, "scalerevenue"),
 			IsDelisted:   getBool(row, idx, "isdelisted"),
+			FirstPrice:   getTime(row, idx, "firstpricedate"),
+			LastPrice:    getTime(row, idx, "lastpricedate"),
 			LastUpdated:  getTime(row, idx, "lastupdated"),
 		}
 		if tr.Ticker != "" {
@@ -181,17 +209,7 @@ func ParseDaily(resp *Response) ([]DailyRow, error) {
 	idx := buildColumnIndex(resp.Datatable.Columns)
 	rows := make([]DailyRow, 0, len(resp.Datatable.Data))
 
-	// Debug: log column names and first row
-	colNames := make([]string, 0, len(resp.Datatable.Columns))
-	for _, col := range resp.Datatable.Columns {
-		colNames = append(colNames, col.Name)
-	}
-	log.Printf("DAILY columns: %v", colNames)
-	if len(resp.Datatable.Data) > 0 {
-		log.Printf("DAILY first row sample: %v", resp.Datatable.Data[0])
-	}
-
-	for i, row := range resp.Datatable.Data {
+	for _, row := range resp.Datatable.Data {
 		date := getTime(row, idx, "date")
 		if date == nil {
 			continue
@@ -214,12 +232,6 @@ func ParseDaily(resp *Response) ([]DailyRow, error) {
 			LastUpdated: getTime(row, idx, "lastupdated"),
 		}
 
-		// Debug: log first parsed row
-		if i == 0 {
-			log.Printf("DAILY first parsed row: Ticker=%s Date=%s Open=%v High=%v Low=%v Close=%v Volume=%v MarketCap=%v",
-				dr.Ticker, dr.Date.Format("2006-01-02"), dr.Open, dr.High, dr.Low, dr.Close, dr.Volume, dr.MarketCap)
-		}
-
 		if dr.Ticker != "" {
 			rows = append(rows, dr)
 		}
@@ -228,6 +240,37 @@ func ParseDaily(resp *Response) ([]DailyRow, error) {
 	return rows, nil
 }
 
+// ParsePrices parses a SHARADAR/SEP or SHARADAR/SFP response into typed rows.
+func ParsePrices(resp *Response) ([]PriceRow, error) {
+	idx := buildColumnIndex(resp.Datatable.Columns)
+	rows := make([]PriceRow, 0, len(resp.Datatable.Data))
+
+	for _, row := range resp.Datatable.Data {
+		date := getTime(row, idx, "date")
+		if date == nil {
+			continue
+		}
+
+		pr := PriceRow{
+			Ticker:      getString(row, idx, "ticker"),
+			Date:        *date,
+			Open:        getDecimal(row, idx, "open"),
+			High:        getDecimal(row, idx, "high"),
+			Low:         getDecimal(row, idx, "low"),
+			Close:       getDecimal(row, idx, "close"),
+			Volume:      getInt64(row, idx, "volume"),
+			CloseAdj:    getDecimal(row, idx, "closeadj"),
+			CloseUnadj:  getDecimal(row, idx, "closeunadj"),
+			LastUpdated: getTime(row, idx, "lastupdated"),
+		}
+		if pr.Ticker != "" {
+			rows = append(rows, pr)
+		}
+	}
+
+	return rows, nil
+}
+
 // ParseSP500 parses a SHARADAR/SP500 response into typed rows.
 func ParseSP500(resp *Response) ([]SP500Row, error) {
 	idx := buildColumnIndex(resp.Datatable.Columns)
@@ -240,8 +283,15 @@ func ParseSP500(resp *Response) ([]SP500Row, error) {
 			Action:    getString(row, idx, "action"),
 			Ticker:    getString(row, idx, "ticker"),
 			Name:      getString(row, idx, "name"),
-			Conticker: getString(row, idx, "conticker"),
-			Conname:   getString(row, idx, "conname"),
+			Conticker: getString(row, idx, "contraticker"),
+			Conname:   getString(row, idx, "contraname"),
+		}
+		// Older exports abbreviate the contra columns
+		if sr.Conticker == "" {
+			sr.Conticker = getString(row, idx, "conticker")
+		}
+		if sr.Conname == "" {
+			sr.Conname = getString(row, idx, "conname")
 		}
 		if date != nil {
 			sr.Date = *date
@@ -0,0 +1,198 @@
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// validColumn matches Tables API column names such as "closeunadj" or "date_key".
+var validColumn = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// reservedParams are set by the client itself and can't be filtered on.
+var reservedParams = map[string]bool{
+	"api_key":         true,
+	"qopts.cursor_id": true,
+	"qopts.export":    true,
+}
+
+// Query builds the parameters of a Tables API request: the columns to return and
+// row filters. Methods chain and record the first mistake, such as an invalid
+// column name, which Params then returns, so a query is checked before any call.
+//
+//	q := NewQuery().Columns(SF1Schema.Names()...).In("ticker", "AAPL", "MSFT").Gte("lastupdated", since)
+type Query struct {
+	columns []string
+	filters map[string]string
+	err     error
+}
+
+// NewQuery creates an empty query, which returns every column and row.
+func NewQuery() *Query {
+	return &Query{filters: make(map[string]string)}
+}
+
+// Columns limits the response to the given columns (qopts.columns). Parsers read
+// missing columns as nil, so ask for every column the parser needs.
+func (q *Query) Columns(columns ...string) *Query {
+	for _, c := range columns {
+		if !q.check(c) {
+			return q
+		}
+	}
+	q.columns = append(q.columns, columns...)
+	return q
+}
+
+// Eq keeps rows whose column equals value.
+func (q *Query) Eq(column string, value any) *Query {
+	return q.filter(column, "", value)
+}
+
+// In keeps rows whose column is one of values. The API takes these as a comma
+// separated list; an empty list adds no filter.
+func (q *Query) In(column string, values ...string) *Query {
+	if len(values) == 0 {
+		return q
+	}
+	for _, v := range values {
+		if strings.Contains(v, ",") {
+			q.fail(fmt.Errorf("value %q for %s contains a comma", v, column))
+			return q
+		}
+	}
+	return q.filter(column, "", strings.Join(values, ","))
+}
+
+// Gt keeps rows whose column is greater than value.
+func (q *Query) Gt(column string, value any) *Query {
+	return q.filter(column, ".gt", value)
+}
+
+// Gte keeps rows whose column is greater than or equal to value. A zero
+// time.Time adds no filter, so watermarks can be passed as they are.
+func (q *Query) Gte(column string, value any) *Query {
+	return q.filter(column, ".gte", value)
+}
+
+// Lt keeps rows whose column is less than value.
+func (q *Query) Lt(column string, value any) *Query {
+	return q.filter(column, ".lt", value)
+}
+
+// Lte keeps rows whose column is less than or equal to value. A zero time.Time
+// adds no filter.
+func (q *Query) Lte(column string, value any) *Query {
+	return q.filter(column, ".lte", value)
+}
+
+// DateRange keeps rows whose date column is between from and to, inclusive.
+// Either end may be zero for an open range.
+func (q *Query) DateRange(column string, from, to time.Time) *Query {
+	return q.Gte(column, from).Lte(column, to)
+}
+
+// Params returns the request parameters, or the first error recorded while
+// building the query.
+func (q *Query) Params() (map[string]string, error) {
+	if q.err != nil {
+		return nil, fmt.Errorf("invalid query: %w", q.err)
+	}
+
+	params := make(map[string]string, len(q.filters)+1)
+	for k, v := range q.filters {
+		params[k] = v
+	}
+	if len(q.columns) > 0 {
+		params["qopts.columns"] = strings.Join(q.columns, ",")
+	}
+	return params, nil
+}
+
+// String renders the parameters in a stable order for log messages.
+func (q *Query) String() string {
+	params, err := q.Params()
+	if err != nil {
+		return err.Error()
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + params[k]
+	}
+	return strings.Join(parts, "&")
+}
+
+// filter sets column+op to value. Setting the same filter twice is an error
+// rather than a silent overwrite.
+func (q *Query) filter(column, op string, value any) *Query {
+	if !q.check(column) {
+		return q
+	}
+	if t, ok := value.(time.Time); ok && t.IsZero() {
+		return q
+	}
+
+	key := column + op
+	if _, ok := q.filters[key]; ok {
+		q.fail(fmt.Errorf("filter %s set twice", key))
+		return q
+	}
+
+	v, err := formatValue(value)
+	if err != nil {
+		q.fail(fmt.Errorf("%s: %w", key, err))
+		return q
+	}
+	q.filters[key] = v
+	return q
+}
+
+// check validates a column name, recording an error if it isn't one.
+func (q *Query) check(column string) bool {
+	if q.err != nil {
+		return false
+	}
+	if reservedParams[column] || !validColumn.MatchString(column) {
+		q.fail(fmt.Errorf("invalid column %q", column))
+		return false
+	}
+	return true
+}
+
+// fail records the first error.
+func (q *Query) fail(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// formatValue renders a filter value the way the API expects it.
+func formatValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return "", fmt.Errorf("empty value")
+		}
+		return v, nil
+	case time.Time:
+		return v.Format("2006-01-02"), nil
+	case bool:
+		if v {
+			return "Y", nil
+		}
+		return "N", nil
+	case int, int32, int64, float64:
+		return fmt.Sprint(v), nil
+	case fmt.Stringer: // e.g. decimal.Decimal
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("unsupported value type %T", value)
+}
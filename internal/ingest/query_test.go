@@ -0,0 +1,53 @@
+package ingest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryParams(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	params, err := NewQuery().
+		Columns("ticker", "date", "close").
+		In("ticker", "AAPL", "MSFT").
+		DateRange("date", from, to).
+		Gte("lastupdated", time.Time{}). // Zero watermark, no filter
+		Params()
+	if err != nil {
+		t.Fatalf("Params: %v", err)
+	}
+
+	want := map[string]string{
+		"qopts.columns": "ticker,date,close",
+		"ticker":        "AAPL,MSFT",
+		"date.gte":      "2024-01-01",
+		"date.lte":      "2024-03-31",
+	}
+	if len(params) != len(want) {
+		t.Errorf("params = %v, want %v", params, want)
+	}
+	for k, v := range want {
+		if params[k] != v {
+			t.Errorf("%s = %q, want %q", k, params[k], v)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	tests := map[string]*Query{
+		"invalid column":   NewQuery().Columns("ticker", "close; drop"),
+		"reserved param":   NewQuery().Eq("api_key", "x"),
+		"filter twice":     NewQuery().Gte("date", "2024-01-01").Gte("date", "2024-02-01"),
+		"comma in value":   NewQuery().In("ticker", "AAPL,MSFT"),
+		"empty value":      NewQuery().Eq("dimension", ""),
+		"unsupported type": NewQuery().Eq("ticker", []string{"AAPL"}),
+	}
+
+	for name, q := range tests {
+		if _, err := q.Params(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultCallQuota is the Nasdaq Data Link daily call limit for authenticated users.
+const DefaultCallQuota = 50000
+
+// ErrQuotaExceeded is returned instead of making a call once today's quota is used up.
+var ErrQuotaExceeded = errors.New("daily API quota exceeded")
+
+// Usage is the API usage of one UTC day against the configured quotas.
+type Usage struct {
+	Day       time.Time `json:"day"`
+	Calls     int64     `json:"calls"`
+	Rows      int64     `json:"rows"`
+	Throttled int64     `json:"throttled"`  // 429 responses
+	CallQuota int64     `json:"call_quota"` // 0 = unlimited
+	RowQuota  int64     `json:"row_quota"`  // 0 = unlimited
+}
+
+// CallsLeft returns the calls left today, or -1 when calls are unlimited.
+func (u Usage) CallsLeft() int64 {
+	if u.CallQuota <= 0 {
+		return -1
+	}
+	return max(u.CallQuota-u.Calls, 0)
+}
+
+// RowsLeft returns the rows left today, or -1 when rows are unlimited.
+func (u Usage) RowsLeft() int64 {
+	if u.RowQuota <= 0 {
+		return -1
+	}
+	return max(u.RowQuota-u.Rows, 0)
+}
+
+// UsageStore persists daily API usage, so quota tracking survives restarts and
+// is shared by every process using the same key.
+type UsageStore interface {
+	GetUsage(ctx context.Context, day time.Time) (Usage, error)
+	AddUsage(ctx context.Context, day time.Time, calls, rows, throttled int64) error
+}
+
+// WithQuota sets the daily call and row quotas; 0 means unlimited.
+func WithQuota(calls, rows int64) Option {
+	return func(c *Client) {
+		c.usage.callQuota = calls
+		c.usage.rowQuota = rows
+	}
+}
+
+// WithUsageStore persists usage to store. Without one, usage is only counted in memory.
+func WithUsageStore(store UsageStore) Option {
+	return func(c *Client) {
+		c.usage.store = store
+	}
+}
+
+// usageTracker counts today's calls and rows and refuses calls past the quota.
+// Calls are reserved before they are made, so parallel fetchers can't overshoot.
+// Counters change under mu; the store is written after it is released.
+type usageTracker struct {
+	mu        sync.Mutex
+	store     UsageStore
+	callQuota int64
+	rowQuota  int64
+	today     Usage
+	loaded    bool      // today includes what the store had, so other runs' usage counts
+	retryAt   time.Time // When to try the store again after a failed load
+}
+
+// usageLoadRetry is how long to wait before reading usage again after a failed read.
+const usageLoadRetry = 30 * time.Second
+
+// dayOf returns the UTC day of t; quotas reset at midnight UTC.
+func dayOf(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// load switches to the current day and reads what other runs already used today,
+// retrying on later calls until the read succeeds. Must be called with mu held.
+func (u *usageTracker) load(ctx context.Context) {
+	now := time.Now()
+	if day := dayOf(now); !u.today.Day.Equal(day) {
+		u.today = Usage{Day: day}
+		u.loaded = u.store == nil
+		u.retryAt = time.Time{}
+	}
+	if u.loaded || now.Before(u.retryAt) {
+		return
+	}
+
+	stored, err := u.store.GetUsage(ctx, u.today.Day)
+	if err != nil {
+		log.Printf("Error loading API usage, retrying in %s: %v", usageLoadRetry, err)
+		u.retryAt = now.Add(usageLoadRetry)
+		return
+	}
+	// The store already has this process's counts, unless saving them failed
+	u.today.Calls = max(u.today.Calls, stored.Calls)
+	u.today.Rows = max(u.today.Rows, stored.Rows)
+	u.today.Throttled = max(u.today.Throttled, stored.Throttled)
+	u.loaded = true
+}
+
+// Usage returns today's usage and quotas.
+func (u *usageTracker) Usage(ctx context.Context) Usage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.load(ctx)
+	usage := u.today
+	usage.CallQuota = u.callQuota
+	usage.RowQuota = u.rowQuota
+	return usage
+}
+
+// reserve counts a call about to be made, or returns ErrQuotaExceeded.
+func (u *usageTracker) reserve(ctx context.Context) error {
+	u.mu.Lock()
+	u.load(ctx)
+	if u.callQuota > 0 && u.today.Calls >= u.callQuota {
+		defer u.mu.Unlock()
+		return fmt.Errorf("%w: %d of %d calls used", ErrQuotaExceeded, u.today.Calls, u.callQuota)
+	}
+	if u.rowQuota > 0 && u.today.Rows >= u.rowQuota {
+		defer u.mu.Unlock()
+		return fmt.Errorf("%w: %d of %d rows used", ErrQuotaExceeded, u.today.Rows, u.rowQuota)
+	}
+	u.today.Calls++
+	day := u.today.Day
+	u.mu.Unlock()
+
+	u.save(ctx, day, 1, 0, 0)
+	return nil
+}
+
+// addRows counts the rows returned by a call.
+func (u *usageTracker) addRows(ctx context.Context, rows int) {
+	u.add(ctx, 0, int64(rows), 0)
+}
+
+// addThrottled counts a 429 response.
+func (u *usageTracker) addThrottled(ctx context.Context) {
+	u.add(ctx, 0, 0, 1)
+}
+
+// add counts usage that isn't checked against the quota.
+func (u *usageTracker) add(ctx context.Context, calls, rows, throttled int64) {
+	u.mu.Lock()
+	u.load(ctx)
+	u.today.Calls += calls
+	u.today.Rows += rows
+	u.today.Throttled += throttled
+	day := u.today.Day
+	u.mu.Unlock()
+
+	u.save(ctx, day, calls, rows, throttled)
+}
+
+// save adds to the stored usage of day. Failures are logged: losing a count is
+// better than failing the fetch. Must be called without mu held, so a slow store
+// doesn't serialize the fetchers.
+func (u *usageTracker) save(ctx context.Context, day time.Time, calls, rows, throttled int64) {
+	if u.store == nil {
+		return
+	}
+	// Still recorded when the call's context was just cancelled
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := u.store.AddUsage(ctx, day, calls, rows, throttled); err != nil {
+		log.Printf("Error saving API usage: %v", err)
+	}
+}
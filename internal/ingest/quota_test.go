@@ -0,0 +1,71 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStore is a UsageStore in memory whose reads can be made to fail.
+type memoryStore struct {
+	mu      sync.Mutex
+	usage   Usage
+	readErr error
+}
+
+func (s *memoryStore) GetUsage(_ context.Context, day time.Time) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.readErr != nil {
+		return Usage{}, s.readErr
+	}
+	return s.usage, nil
+}
+
+func (s *memoryStore) AddUsage(_ context.Context, day time.Time, calls, rows, throttled int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage.Calls += calls
+	s.usage.Rows += rows
+	s.usage.Throttled += throttled
+	return nil
+}
+
+func TestUsageLoadRetries(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{usage: Usage{Calls: 9, Rows: 100}, readErr: errors.New("database down")}
+	u := &usageTracker{store: store, callQuota: 12}
+
+	// Counted in memory and saved while the store can't be read
+	if err := u.reserve(ctx); err != nil {
+		t.Fatal(err)
+	}
+	u.addRows(ctx, 5)
+	if got := u.Usage(ctx); got.Calls != 1 || got.Rows != 5 {
+		t.Errorf("usage before load = %+v, want 1 call and 5 rows", got)
+	}
+
+	// The next read after the retry delay picks up the other runs' usage
+	store.mu.Lock()
+	store.readErr = nil
+	store.mu.Unlock()
+	u.retryAt = time.Time{}
+
+	if got := u.Usage(ctx); got.Calls != 10 || got.Rows != 105 {
+		t.Errorf("usage after load = %+v, want 10 calls and 105 rows", got)
+	}
+
+	for range 2 {
+		if err := u.reserve(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := u.reserve(ctx); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("13th call: err = %v, want ErrQuotaExceeded", err)
+	}
+	if store.usage.Calls != 12 {
+		t.Errorf("stored calls = %d, want 12", store.usage.Calls)
+	}
+}
@@ -0,0 +1,219 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Replay is a DataSource that serves recorded Tables API responses from disk, so
+// the app can ingest offline and handlers can be exercised without the network.
+//
+// Each table is read from dir/<table>.json, plus any further pages saved as
+// dir/<table>.<n>.json, e.g. fixtures/SHARADAR/SF1.json and SHARADAR/SF1.2.json.
+// The files are API responses as returned by the Tables API. Requests are
+// answered by filtering the recorded rows the way the API filters them, so a
+// recording of a few tickers serves any query about them. Tables are loaded
+// whole and kept in memory, which suits fixtures rather than full tables.
+type Replay struct {
+	dir string
+
+	mu     sync.Mutex
+	tables map[string]*Response
+}
+
+// NewReplay creates a replay source reading recordings from dir.
+func NewReplay(dir string) *Replay {
+	return &Replay{
+		dir:    dir,
+		tables: make(map[string]*Response),
+	}
+}
+
+// rowFilter reports whether a recorded row matches a request.
+type rowFilter func(row []interface{}, idx map[string]int) bool
+
+// inColumn matches rows whose column is one of values; no values match everything.
+func inColumn(col string, values []string) rowFilter {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return func(row []interface{}, idx map[string]int) bool {
+		return len(set) == 0 || set[getString(row, idx, col)]
+	}
+}
+
+// onOrAfter matches rows whose date column is on or after since, like col.gte.
+func onOrAfter(col string, since time.Time) rowFilter {
+	return func(row []interface{}, idx map[string]int) bool {
+		if since.IsZero() {
+			return true
+		}
+		t := getTime(row, idx, col)
+		return t != nil && !t.Before(since.Truncate(24*time.Hour))
+	}
+}
+
+// load returns a table's recorded pages merged into one response.
+func (r *Replay) load(table string) (*Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if resp, ok := r.tables[table]; ok {
+		return resp, nil
+	}
+
+	base := filepath.Join(r.dir, filepath.FromSlash(table))
+	pages, err := filepath.Glob(base + ".*.json")
+	if err != nil {
+		return nil, fmt.Errorf("listing %s recordings: %w", table, err)
+	}
+	sort.Strings(pages)
+	paths := append([]string{base + ".json"}, pages...)
+
+	merged := &Response{}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening %s recording: %w", table, err)
+		}
+		page, err := decodePage(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+
+		if len(merged.Datatable.Columns) == 0 {
+			merged.Datatable.Columns = page.Datatable.Columns
+		}
+		merged.Datatable.Data = append(merged.Datatable.Data, page.Datatable.Data...)
+	}
+
+	log.Printf("Loaded %d recorded %s rows from %s", len(merged.Datatable.Data), table, r.dir)
+	r.tables[table] = merged
+	return merged, nil
+}
+
+// query returns the recorded rows of a table matching every filter.
+func (r *Replay) query(table string, filters ...rowFilter) (*Response, error) {
+	all, err := r.load(table)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := buildColumnIndex(all.Datatable.Columns)
+	resp := &Response{}
+	resp.Datatable.Columns = all.Datatable.Columns
+	for _, row := range all.Datatable.Data {
+		match := true
+		for _, f := range filters {
+			if !f(row, idx) {
+				match = false
+				break
+			}
+		}
+		if match {
+			resp.Datatable.Data = append(resp.Datatable.Data, row)
+		}
+	}
+
+	return resp, nil
+}
+
+// FetchTickers returns the recorded SHARADAR/TICKERS rows for SF1.
+func (r *Replay) FetchTickers(ctx context.Context, tickers []string) ([]TickerRow, error) {
+	resp, err := r.query("SHARADAR/TICKERS", inColumn("ticker", tickers), func(row []interface{}, idx map[string]int) bool {
+		_, ok := idx["table"]
+		return !ok || getString(row, idx, "table") == "SF1"
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching tickers: %w", err)
+	}
+	return ParseTickers(resp)
+}
+
+// FetchSF1Stream sends the recorded SHARADAR/SF1 rows of each group as one batch.
+func (r *Replay) FetchSF1Stream(ctx context.Context, groups []TickerGroup, dimension string, maxParallel int) <-chan SF1Batch {
+	var dimensions []string
+	if dimension != "" {
+		dimensions = []string{dimension}
+	}
+
+	return replayStream(ctx, groups, func(g TickerGroup) ([]SF1Row, error) {
+		resp, err := r.query("SHARADAR/SF1", inColumn("ticker", g.Tickers), inColumn("dimension", dimensions), onOrAfter("lastupdated", g.Since))
+		if err != nil {
+			return nil, fmt.Errorf("fetching SF1: %w", err)
+		}
+		return ParseSF1(resp)
+	})
+}
+
+// FetchDailyStream sends the recorded SHARADAR/DAILY rows of each group as one batch.
+func (r *Replay) FetchDailyStream(ctx context.Context, groups []TickerGroup, maxParallel int) <-chan DailyBatch {
+	return replayStream(ctx, groups, func(g TickerGroup) ([]DailyRow, error) {
+		resp, err := r.query("SHARADAR/DAILY", inColumn("ticker", g.Tickers), onOrAfter("date", g.Since))
+		if err != nil {
+			return nil, fmt.Errorf("fetching daily: %w", err)
+		}
+		return ParseDaily(resp)
+	})
+}
+
+// FetchSEPStream sends the recorded SHARADAR/SEP rows of each group as one batch.
+func (r *Replay) FetchSEPStream(ctx context.Context, groups []TickerGroup, maxParallel int) <-chan PriceBatch {
+	return replayStream(ctx, groups, func(g TickerGroup) ([]PriceRow, error) {
+		resp, err := r.query("SHARADAR/SEP", inColumn("ticker", g.Tickers), onOrAfter("date", g.Since))
+		if err != nil {
+			return nil, fmt.Errorf("fetching SHARADAR/SEP: %w", err)
+		}
+		return ParsePrices(resp)
+	})
+}
+
+// replayStream sends the rows read for each chunk of groups as one batch, in order.
+func replayStream[T any](ctx context.Context, groups []TickerGroup, read func(TickerGroup) ([]T, error)) <-chan Batch[T] {
+	ch := make(chan Batch[T])
+
+	go func() {
+		defer close(ch)
+
+		for _, g := range chunkGroups(groups) {
+			rows, err := read(g)
+			select {
+			case ch <- Batch[T]{Rows: rows, Error: err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// FetchSFP returns the recorded SHARADAR/SFP rows of tickers on or after since.
+func (r *Replay) FetchSFP(ctx context.Context, tickers []string, since time.Time) ([]PriceRow, error) {
+	if len(tickers) == 0 {
+		return nil, fmt.Errorf("at least one ticker required for SFP fetch")
+	}
+
+	resp, err := r.query("SHARADAR/SFP", inColumn("ticker", tickers), onOrAfter("date", since))
+	if err != nil {
+		return nil, fmt.Errorf("fetching SHARADAR/SFP: %w", err)
+	}
+	return ParsePrices(resp)
+}
+
+// FetchSP500History returns every recorded SHARADAR/SP500 row.
+func (r *Replay) FetchSP500History(ctx context.Context) ([]SP500Row, error) {
+	resp, err := r.query("SHARADAR/SP500")
+	if err != nil {
+		return nil, fmt.Errorf("fetching SP500 history: %w", err)
+	}
+	return ParseSP500(resp)
+}
@@ -0,0 +1,67 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeRecording saves a recorded response under dir/<name>.
+func writeRecording(t *testing.T, dir, name, body string) {
+	t.Helper()
+
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplaySF1Stream(t *testing.T) {
+	dir := t.TempDir()
+	columns := `"columns":[{"name":"ticker"},{"name":"dimension"},{"name":"datekey"},{"name":"lastupdated"},{"name":"revenue"}]`
+	writeRecording(t, dir, "SHARADAR/SF1.json", `{"datatable":{"data":[
+		["AAPL","ARQ","2024-02-02","2024-02-03",119575000000],
+		["AAPL","MRQ","2024-02-02","2024-02-03",119575000000],
+		["MSFT","ARQ","2023-10-25","2023-10-26",56517000000]
+	],`+columns+`},"meta":{"next_cursor_id":"abc"}}`)
+	writeRecording(t, dir, "SHARADAR/SF1.2.json", `{"datatable":{"data":[
+		["AAPL","ARQ","2024-05-03","2024-05-04",90753000000],
+		["GOOGL","ARQ","2024-04-26","2024-04-27",80539000000]
+	],`+columns+`},"meta":{"next_cursor_id":null}}`)
+
+	groups := []TickerGroup{
+		{Tickers: []string{"AAPL"}, Since: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Tickers: []string{"MSFT"}},
+	}
+
+	var rows []SF1Row
+	for batch := range NewReplay(dir).FetchSF1Stream(context.Background(), groups, "ARQ", 1) {
+		if batch.Error != nil {
+			t.Fatalf("batch error: %v", batch.Error)
+		}
+		rows = append(rows, batch.Rows...)
+	}
+
+	// AAPL only from its watermark (second page), MSFT all history, no MRQ or GOOGL
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2: %+v", len(rows), rows)
+	}
+	if rows[0].Ticker != "AAPL" || rows[0].Revenue.String() != "90753000000" {
+		t.Errorf("row 0 = %s revenue %v, want AAPL 90753000000", rows[0].Ticker, rows[0].Revenue)
+	}
+	if rows[1].Ticker != "MSFT" {
+		t.Errorf("row 1 = %s, want MSFT", rows[1].Ticker)
+	}
+}
+
+func TestReplayMissingRecording(t *testing.T) {
+	_, err := NewReplay(t.TempDir()).FetchSFP(context.Background(), []string{"SPY"}, time.Time{})
+	if err == nil {
+		t.Fatal("expected an error for a table without a recording")
+	}
+}
@@ -0,0 +1,230 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSchemaDrift is returned in strict mode when a response lacks a required column.
+var ErrSchemaDrift = errors.New("schema drift")
+
+// ColumnSpec is a column a parser reads: its name, the kind of Tables API type
+// it has, and whether rows are useless without it. Optional columns have a
+// fallback in the parser, such as calendardate falling back to datekey.
+type ColumnSpec struct {
+	Name     string
+	Kind     string // "string", "date" or "number"
+	Optional bool
+}
+
+// Schema is the expected columns of a table.
+type Schema []ColumnSpec
+
+// Names returns the column names, for Query.Columns.
+func (s Schema) Names() []string {
+	names := make([]string, len(s))
+	for i, c := range s {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// Expected schemas of the tables fetched with Query.Columns. A column the API
+// stops returning is read as nil by the parsers and stored as NULL, so every
+// response is checked against these. SHARADAR/DAILY has no OHLC columns, and
+// asking for a column a table lacks fails the request.
+var (
+	TickerSchema = Schema{
+		{Name: "ticker", Kind: "string"},
+		{Name: "name", Kind: "string"},
+		{Name: "exchange", Kind: "string"},
+		{Name: "sector", Kind: "string"},
+		{Name: "industry", Kind: "string"},
+		{Name: "scalerevenue", Kind: "string"},
+		{Name: "isdelisted", Kind: "string"},
+		{Name: "firstpricedate", Kind: "date"},
+		{Name: "lastpricedate", Kind: "date"},
+		{Name: "lastupdated", Kind: "date"},
+	}
+	SF1Schema = Schema{
+		{Name: "ticker", Kind: "string"},
+		{Name: "dimension", Kind: "string"},
+		{Name: "calendardate", Kind: "date", Optional: true},
+		{Name: "datekey", Kind: "date"},
+		{Name: "reportperiod", Kind: "date", Optional: true},
+		{Name: "lastupdated", Kind: "date"},
+		{Name: "revenue", Kind: "number"},
+		{Name: "netinc", Kind: "number"},
+		{Name: "ebitda", Kind: "number"},
+		{Name: "fcf", Kind: "number"},
+		{Name: "roic", Kind: "number"},
+		{Name: "pe", Kind: "number"},
+		{Name: "evebit", Kind: "number"},
+		{Name: "pb", Kind: "number"},
+		{Name: "de", Kind: "number"},
+		{Name: "marketcap", Kind: "number"},
+		{Name: "ev", Kind: "number"},
+		{Name: "price", Kind: "number"},
+	}
+	DailySchema = Schema{
+		{Name: "ticker", Kind: "string"},
+		{Name: "date", Kind: "date"},
+		{Name: "lastupdated", Kind: "date"},
+		{Name: "marketcap", Kind: "number"},
+		{Name: "ev", Kind: "number"},
+		{Name: "pe", Kind: "number"},
+		{Name: "pb", Kind: "number"},
+	}
+	PriceSchema = Schema{
+		{Name: "ticker", Kind: "string"},
+		{Name: "date", Kind: "date"},
+		{Name: "open", Kind: "number"},
+		{Name: "high", Kind: "number"},
+		{Name: "low", Kind: "number"},
+		{Name: "close", Kind: "number"},
+		{Name: "volume", Kind: "number"},
+		{Name: "closeadj", Kind: "number"},
+		{Name: "closeunadj", Kind: "number"},
+		{Name: "lastupdated", Kind: "date"},
+	}
+)
+
+// tableSchemas maps a table to its expected schema. SHARADAR/SP500 isn't
+// checked: it is fetched with every column and older copies name the contra
+// columns differently.
+var tableSchemas = map[string]Schema{
+	"SHARADAR/TICKERS": TickerSchema,
+	"SHARADAR/SF1":     SF1Schema,
+	"SHARADAR/DAILY":   DailySchema,
+	"SHARADAR/SEP":     PriceSchema,
+	"SHARADAR/SFP":     PriceSchema,
+}
+
+// ColumnChange is a column whose type no longer matches its schema.
+type ColumnChange struct {
+	Name     string
+	Expected string // Kind from the schema
+	Got      string // Type as returned by the API, e.g. "String"
+}
+
+// SchemaDrift lists the differences between a response's columns and the
+// expected schema of its table.
+type SchemaDrift struct {
+	Table   string
+	Missing []string // Expected columns not returned
+	Added   []string // Returned columns the schema doesn't know
+	Retyped []ColumnChange
+	Broken  bool // A required column is missing
+}
+
+// Empty reports whether the response matched its schema.
+func (d SchemaDrift) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Added) == 0 && len(d.Retyped) == 0
+}
+
+// Warnings describes the drift, one message per kind of difference.
+func (d SchemaDrift) Warnings() []string {
+	var warnings []string
+	if len(d.Missing) > 0 {
+		warnings = append(warnings, fmt.Sprintf("%s: missing columns %s", d.Table, strings.Join(d.Missing, ", ")))
+	}
+	if len(d.Added) > 0 {
+		warnings = append(warnings, fmt.Sprintf("%s: unexpected columns %s", d.Table, strings.Join(d.Added, ", ")))
+	}
+	for _, c := range d.Retyped {
+		warnings = append(warnings, fmt.Sprintf("%s: column %s is %s, expected %s", d.Table, c.Name, c.Got, c.Expected))
+	}
+	return warnings
+}
+
+// CheckSchema compares the columns of a table's response with its expected
+// schema. Tables without a schema never drift. Columns without a type, as in
+// CSV exports, are only checked by name.
+func CheckSchema(table string, columns []Column) SchemaDrift {
+	drift := SchemaDrift{Table: table}
+	schema, ok := tableSchemas[table]
+	if !ok {
+		return drift
+	}
+
+	got := make(map[string]string, len(columns))
+	for _, c := range columns {
+		got[strings.ToLower(c.Name)] = c.Type
+	}
+
+	known := make(map[string]bool, len(schema))
+	for _, spec := range schema {
+		known[spec.Name] = true
+
+		typ, ok := got[spec.Name]
+		if !ok {
+			drift.Missing = append(drift.Missing, spec.Name)
+			drift.Broken = drift.Broken || !spec.Optional
+			continue
+		}
+		if typ != "" && columnKind(typ) != spec.Kind {
+			drift.Retyped = append(drift.Retyped, ColumnChange{Name: spec.Name, Expected: spec.Kind, Got: typ})
+		}
+	}
+
+	for _, c := range columns {
+		if !known[strings.ToLower(c.Name)] {
+			drift.Added = append(drift.Added, c.Name)
+		}
+	}
+	return drift
+}
+
+// columnKind maps a Tables API column type such as "BigDecimal(15,2)" to the
+// kind of value the parsers expect.
+func columnKind(typ string) string {
+	base, _, _ := strings.Cut(strings.ToLower(typ), "(")
+	switch base {
+	case "string", "text":
+		return "string"
+	case "date", "datetime":
+		return "date"
+	case "bigdecimal", "decimal", "double", "float", "integer", "long":
+		return "number"
+	}
+	return base
+}
+
+// DriftFunc receives the schema drift of a fetch.
+type DriftFunc func(SchemaDrift)
+
+type driftKey struct{}
+
+// WithDriftReporter returns a context whose fetches report schema drift to fn,
+// so warnings reach the run that made the request rather than the client.
+func WithDriftReporter(ctx context.Context, fn DriftFunc) context.Context {
+	return context.WithValue(ctx, driftKey{}, fn)
+}
+
+// WithStrictSchema makes fetches fail with ErrSchemaDrift, before any row is
+// handed on, when a response lacks a required column. Other drift is still
+// only reported.
+func WithStrictSchema() Option {
+	return func(c *Client) {
+		c.strictSchema = true
+	}
+}
+
+// checkSchema reports the drift of a page's columns to the reporter in ctx and
+// returns ErrSchemaDrift for a missing required column in strict mode.
+func (c *Client) checkSchema(ctx context.Context, table string, columns []Column) error {
+	drift := CheckSchema(table, columns)
+	if drift.Empty() {
+		return nil
+	}
+
+	if fn, ok := ctx.Value(driftKey{}).(DriftFunc); ok {
+		fn(drift)
+	}
+	if c.strictSchema && drift.Broken {
+		return fmt.Errorf("%w: %s is missing columns %s", ErrSchemaDrift, table, strings.Join(drift.Missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckSchema(t *testing.T) {
+	columns := []Column{
+		{Name: "ticker", Type: "String"},
+		{Name: "date", Type: "Date"},
+		{Name: "lastupdated", Type: "Date"},
+		{Name: "marketcap", Type: "BigDecimal(15,1)"},
+		{Name: "ev", Type: "String"}, // Retyped
+		{Name: "pe", Type: "BigDecimal(15,1)"},
+		{Name: "evebitda", Type: "BigDecimal(15,1)"}, // Added, pb missing
+	}
+
+	drift := CheckSchema("SHARADAR/DAILY", columns)
+	if fmt.Sprint(drift.Missing) != "[pb]" || fmt.Sprint(drift.Added) != "[evebitda]" || !drift.Broken {
+		t.Errorf("drift = %+v, want pb missing and evebitda added", drift)
+	}
+	if len(drift.Retyped) != 1 || drift.Retyped[0].Name != "ev" || drift.Retyped[0].Expected != "number" {
+		t.Errorf("retyped = %+v, want ev", drift.Retyped)
+	}
+	if len(drift.Warnings()) != 3 {
+		t.Errorf("warnings = %q, want 3", drift.Warnings())
+	}
+
+	// Optional columns aren't required, and CSV columns have no type
+	drift = CheckSchema("SHARADAR/SF1", columnsOf(SF1Schema, "calendardate"))
+	if drift.Broken || fmt.Sprint(drift.Missing) != "[calendardate]" || len(drift.Retyped) != 0 {
+		t.Errorf("drift = %+v, want only calendardate missing", drift)
+	}
+
+	if drift := CheckSchema("SHARADAR/SP500", nil); !drift.Empty() {
+		t.Errorf("SP500 drift = %+v, want none", drift)
+	}
+}
+
+func TestStrictSchema(t *testing.T) {
+	// SHARADAR/DAILY without pb
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"datatable":{"data":[["AAPL","2024-06-28","2024-06-29",3293.2,3300.1,33.1]],"columns":[`+
+			`{"name":"ticker","type":"String"},{"name":"date","type":"Date"},{"name":"lastupdated","type":"Date"},`+
+			`{"name":"marketcap","type":"BigDecimal(15,1)"},{"name":"ev","type":"BigDecimal(15,1)"},{"name":"pe","type":"BigDecimal(15,1)"}]},`+
+			`"meta":{"next_cursor_id":null}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	var warnings []string
+	ctx := WithDriftReporter(context.Background(), func(d SchemaDrift) {
+		warnings = append(warnings, d.Warnings()...)
+	})
+
+	// Lenient: the rows are stored and the drift reported
+	rows, err := NewClient("key", WithBaseURL(srv.URL)).fetchAll(ctx, time.Time{})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("lenient fetch = %d rows, %v; want 1 row", len(rows), err)
+	}
+	if len(warnings) != 1 || warnings[0] != "SHARADAR/DAILY: missing columns pb" {
+		t.Errorf("warnings = %q", warnings)
+	}
+
+	// Strict: no rows reach the caller
+	rows, err = NewClient("key", WithBaseURL(srv.URL), WithStrictSchema()).fetchAll(ctx, time.Time{})
+	if !errors.Is(err, ErrSchemaDrift) || len(rows) != 0 {
+		t.Errorf("strict fetch = %d rows, %v; want ErrSchemaDrift and no rows", len(rows), err)
+	}
+}
+
+// columnsOf returns a schema's columns without a type, leaving out skip.
+func columnsOf(s Schema, skip string) []Column {
+	var columns []Column
+	for _, name := range s.Names() {
+		if name != skip {
+			columns = append(columns, Column{Name: name})
+		}
+	}
+	return columns
+}
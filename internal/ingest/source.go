@@ -0,0 +1,53 @@
+package ingest
+
+import (
+	"context"
+	"time"
+)
+
+// DataSource supplies the Sharadar tables the pipeline ingests. Client fetches
+// them from Nasdaq Data Link and Replay reads recorded responses from disk; other
+// vendors can be added by mapping their data onto the same row types.
+//
+// Streams are closed when the fetch is done. A batch with an Error ends its
+// group; callers stop reading by cancelling ctx.
+type DataSource interface {
+	// FetchTickers returns company metadata, for every company when tickers is empty.
+	FetchTickers(ctx context.Context, tickers []string) ([]TickerRow, error)
+
+	// FetchSF1Stream streams fundamentals of one dimension, each group from its watermark.
+	FetchSF1Stream(ctx context.Context, groups []TickerGroup, dimension string, maxParallel int) <-chan SF1Batch
+
+	// FetchDailyStream streams daily valuation metrics, each group from its watermark.
+	FetchDailyStream(ctx context.Context, groups []TickerGroup, maxParallel int) <-chan DailyBatch
+
+	// FetchSEPStream streams equity prices, each group from its watermark.
+	FetchSEPStream(ctx context.Context, groups []TickerGroup, maxParallel int) <-chan PriceBatch
+
+	// FetchSFP returns fund prices on or after since for a small set of tickers.
+	FetchSFP(ctx context.Context, tickers []string, since time.Time) ([]PriceRow, error)
+
+	// FetchSP500History returns the S&P 500 membership events and current constituents.
+	FetchSP500History(ctx context.Context) ([]SP500Row, error)
+}
+
+// UsageReporter is implemented by sources with a daily API quota.
+type UsageReporter interface {
+	Usage(ctx context.Context) Usage
+}
+
+// Exporter is implemented by sources that can download a whole table at once,
+// which is much faster than paging for full-history backfills of every ticker.
+// CanExport is false when exports are unavailable and pages must be used instead.
+type Exporter interface {
+	CanExport() bool
+	ExportSF1Stream(ctx context.Context, dimension string) <-chan SF1Batch
+	ExportDailyStream(ctx context.Context) <-chan DailyBatch
+}
+
+var (
+	_ DataSource    = (*Client)(nil)
+	_ UsageReporter = (*Client)(nil)
+	_ Exporter      = (*Client)(nil)
+	_ DataSource    = (*Replay)(nil)
+)
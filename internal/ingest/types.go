@@ -24,6 +24,12 @@ type Column struct {
 	Type string `json:"type"`
 }
 
+// TickerGroup is a set of tickers fetched from the same incremental watermark.
+type TickerGroup struct {
+	Tickers []string
+	Since   time.Time // Zero fetches all history
+}
+
 // TickerRow represents a row from SHARADAR/TICKERS table.
 type TickerRow struct {
 	Ticker       string
@@ -33,6 +39,8 @@ type TickerRow struct {
 	Industry     string
 	ScaleRevenue string
 	IsDelisted   bool
+	FirstPrice   *time.Time // First date with price data
+	LastPrice    *time.Time // Last date with price data (the delisting date for delisted tickers)
 	LastUpdated  *time.Time
 }
 
@@ -78,12 +86,27 @@ type DailyRow struct {
 	LastUpdated     *time.Time
 }
 
+// PriceRow represents a row from SHARADAR/SEP (equities) or SHARADAR/SFP (funds),
+// which share the same columns. Open, high, low and close are split-adjusted.
+type PriceRow struct {
+	Ticker      string
+	Date        time.Time
+	Open        *decimal.Decimal
+	High        *decimal.Decimal
+	Low         *decimal.Decimal
+	Close       *decimal.Decimal
+	Volume      *int64
+	CloseAdj    *decimal.Decimal // Adjusted for splits and dividends
+	CloseUnadj  *decimal.Decimal
+	LastUpdated *time.Time
+}
+
 // SP500Row represents a row from SHARADAR/SP500 table.
 type SP500Row struct {
 	Date      time.Time
 	Action    string // "current", "added", "removed"
 	Ticker    string
 	Name      string
-	Conticker string
+	Conticker string // Ticker removed (for "added") or added (for "removed") in the same change
 	Conname   string
 }
\ No newline at end of file
@@ -0,0 +1,373 @@
+// Package jobs runs ingestion steps and records each one in the ingest_runs
+// table, either in the background or in the caller's goroutine.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+)
+
+// ErrNotRunning is returned when cancelling a job that is not running in this process.
+var ErrNotRunning = errors.New("job is not running")
+
+// ErrAlreadyRunning is returned when a job is started while another ingestion
+// run holds the lock, in this or another instance.
+var ErrAlreadyRunning = errors.New("ingestion is already running")
+
+// lockKey is the Postgres advisory lock held while any ingestion job runs, so
+// two app instances never ingest at once.
+const lockKey = 0x5C4ED01E
+
+// Func is the work done by a job. It reports progress as it goes and returns the
+// number of rows upserted.
+type Func func(ctx context.Context, progress *Progress) (int, error)
+
+// Spec describes a run for the history table.
+type Spec struct {
+	Kind     string // Pipeline step: tickers, fundamentals, daily, prices, benchmarks, sp500, dead_letters
+	Endpoint string // What started the run: the API path, or "scheduler"
+	Params   any    // Stored as JSON
+}
+
+// Runner starts jobs and keeps their cancel functions so they can be stopped.
+// Jobs never overlap: each holds the ingestion lock while it runs.
+type Runner struct {
+	repo        *db.RunRepository
+	deadLetters *db.DeadLetterRepository
+
+	running sync.Mutex // Held with the advisory lock, see Lock
+
+	mu      sync.Mutex
+	cancels map[int64]context.CancelFunc
+}
+
+// NewRunner creates a new job runner.
+func NewRunner(repo *db.RunRepository, deadLetters *db.DeadLetterRepository) *Runner {
+	return &Runner{
+		repo:        repo,
+		deadLetters: deadLetters,
+		cancels:     make(map[int64]context.CancelFunc),
+	}
+}
+
+// Recover marks runs left running by a stopped process as failed. It only does
+// so holding the ingestion lock, see Lock; while another instance is ingesting
+// its runs are left alone and recovered the next time a job starts.
+func (r *Runner) Recover(ctx context.Context) error {
+	session, err := r.Lock(ctx)
+	if errors.Is(err, ErrAlreadyRunning) {
+		log.Printf("Ingestion is running in another instance, not recovering ingest runs")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	session.Unlock()
+	return nil
+}
+
+// Start records a new run and executes fn in the background. The job's context
+// is independent of ctx, which is only used to take the lock and create the run
+// row. Returns ErrAlreadyRunning if another job is running.
+func (r *Runner) Start(ctx context.Context, spec Spec, fn Func) (int64, error) {
+	session, err := r.Lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := r.repo.CreateRun(ctx, spec.Kind, spec.Endpoint, spec.Params)
+	if err != nil {
+		session.Unlock()
+		return 0, err
+	}
+
+	jobCtx, cancel := context.WithCancel(context.Background())
+
+	r.mu.Lock()
+	r.cancels[id] = cancel
+	r.mu.Unlock()
+
+	log.Printf("Started %s job %d", spec.Kind, id)
+
+	go func() {
+		defer session.Unlock()
+		r.run(jobCtx, id, spec.Kind, fn)
+	}()
+
+	return id, nil
+}
+
+// Run records a run and executes fn in the calling goroutine, for callers that
+// need the result before moving on. The run can still be cancelled by ID.
+// Returns ErrAlreadyRunning if another job is running.
+func (r *Runner) Run(ctx context.Context, spec Spec, fn Func) (int, error) {
+	session, err := r.Lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer session.Unlock()
+
+	return session.Run(ctx, spec, fn)
+}
+
+// Session holds the ingestion lock across several jobs, so a sequence such as
+// the scheduler's nightly run can't interleave with jobs started elsewhere.
+type Session struct {
+	runner *Runner
+	once   sync.Once
+	unlock func()
+}
+
+// Lock takes the ingestion lock without waiting: a mutex for this process and
+// a Postgres advisory lock for other instances. Returns ErrAlreadyRunning if
+// either is held. The session's Unlock must be called to release it.
+//
+// Every run executes under the lock, so once it is taken any run still recorded
+// as running belongs to a process that stopped, and is marked as failed.
+func (r *Runner) Lock(ctx context.Context) (*Session, error) {
+	if !r.running.TryLock() {
+		return nil, ErrAlreadyRunning
+	}
+
+	unlock, ok, err := r.repo.TryAdvisoryLock(ctx, lockKey)
+	if err != nil {
+		r.running.Unlock()
+		return nil, err
+	}
+	if !ok {
+		r.running.Unlock()
+		return nil, ErrAlreadyRunning
+	}
+
+	count, err := r.repo.FailRunningRuns(ctx, "interrupted by server restart")
+	if err != nil {
+		log.Printf("Error failing interrupted ingest runs: %v", err)
+	} else if count > 0 {
+		log.Printf("Marked %d interrupted ingest runs as failed", count)
+	}
+
+	return &Session{
+		runner: r,
+		unlock: func() {
+			unlock()
+			r.running.Unlock()
+		},
+	}, nil
+}
+
+// Run records a run and executes fn in the calling goroutine under the
+// session's lock.
+func (s *Session) Run(ctx context.Context, spec Spec, fn Func) (int, error) {
+	r := s.runner
+	id, err := r.repo.CreateRun(ctx, spec.Kind, spec.Endpoint, spec.Params)
+	if err != nil {
+		return 0, err
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+
+	r.mu.Lock()
+	r.cancels[id] = cancel
+	r.mu.Unlock()
+
+	log.Printf("Running %s job %d", spec.Kind, id)
+
+	return r.run(jobCtx, id, spec.Kind, fn)
+}
+
+// Unlock releases the ingestion lock. Further calls do nothing.
+func (s *Session) Unlock() {
+	s.once.Do(s.unlock)
+}
+
+// Cancel stops a running job through its context.
+func (r *Runner) Cancel(id int64) error {
+	r.mu.Lock()
+	cancel, ok := r.cancels[id]
+	r.mu.Unlock()
+
+	if !ok {
+		return ErrNotRunning
+	}
+
+	log.Printf("Cancelling job %d", id)
+	cancel()
+	return nil
+}
+
+// Get returns a run's current state.
+func (r *Runner) Get(ctx context.Context, id int64) (*models.IngestRun, error) {
+	return r.repo.GetRun(ctx, id)
+}
+
+// List returns the most recent runs, newest first.
+func (r *Runner) List(ctx context.Context, limit int) ([]models.IngestRun, error) {
+	return r.repo.ListRuns(ctx, limit)
+}
+
+// RanSince reports whether a run from endpoint started at or after t, in this or
+// another instance.
+func (r *Runner) RanSince(ctx context.Context, endpoint string, t time.Time) (bool, error) {
+	return r.repo.RanSince(ctx, endpoint, t)
+}
+
+func (r *Runner) run(ctx context.Context, id int64, kind string, fn Func) (int, error) {
+	start := time.Now()
+	progress := &Progress{id: id, repo: r.repo, deadLetters: r.deadLetters}
+
+	defer func() {
+		r.mu.Lock()
+		cancel := r.cancels[id]
+		delete(r.cancels, id)
+		r.mu.Unlock()
+		cancel()
+	}()
+
+	count, err := fn(ctx, progress)
+
+	status := models.RunSucceeded
+	message := fmt.Sprintf("Successfully ingested %d rows", count)
+	switch {
+	case errors.Is(err, context.Canceled):
+		status = models.RunCancelled
+		message = fmt.Sprintf("Cancelled after %d rows", count)
+	case err != nil:
+		status = models.RunFailed
+		message = err.Error()
+	}
+
+	// The job context may be cancelled, so the final write uses its own
+	finishCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if ferr := r.repo.FinishRun(finishCtx, id, status, message, progress.Totals()); ferr != nil {
+		log.Printf("Error finishing run %d: %v", id, ferr)
+	}
+
+	log.Printf("Job %d (%s) %s: %d rows in %v", id, kind, status, count, time.Since(start))
+
+	return count, err
+}
+
+// Progress records a run's watermark, batches, rows, errors, warnings and
+// rejected rows as they happen. It implements pipeline.Progress.
+type Progress struct {
+	id          int64
+	repo        *db.RunRepository
+	deadLetters *db.DeadLetterRepository
+
+	mu       sync.Mutex
+	totals   db.RunProgress
+	since    *time.Time
+	groups   int
+	warnings map[string]bool // Already recorded, so repeats skip the database
+}
+
+// Planned records the watermark groups a fetch will use. Steps that fetch
+// several times (one per SF1 dimension) report each plan; the earliest since is kept.
+func (p *Progress) Planned(groups []ingest.TickerGroup) {
+	p.mu.Lock()
+	p.groups += len(groups)
+	for _, g := range groups {
+		if g.Since.IsZero() {
+			continue
+		}
+		if p.since == nil || g.Since.Before(*p.since) {
+			since := g.Since
+			p.since = &since
+		}
+	}
+	since, count := p.since, p.groups
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.repo.SetRunWatermark(ctx, p.id, since, count); err != nil {
+		log.Printf("Error recording run %d watermark: %v", p.id, err)
+	}
+}
+
+// BatchDone adds a completed batch, persists the running totals and dead-letters
+// the batch's rejected rows.
+func (p *Progress) BatchDone(stats db.UpsertStats) {
+	p.mu.Lock()
+	p.totals.Batches++
+	p.totals.Rows += int64(stats.Rows)
+	p.totals.ValuesOverflowed += stats.Overflowed
+	p.totals.RowsFailed += stats.FailedRows
+	p.totals.RowsInserted += int64(stats.Inserted)
+	p.totals.RowsUpdated += int64(stats.Updated)
+	p.totals.RowsUnchanged += int64(stats.Unchanged)
+	totals := p.totals
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.repo.UpdateRunProgress(ctx, p.id, totals); err != nil {
+		log.Printf("Error updating run %d progress: %v", p.id, err)
+	}
+
+	if len(stats.Rejected) == 0 {
+		return
+	}
+
+	// A failed database batch rejects every row in it, so allow longer than a progress update
+	dlCtx, dlCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer dlCancel()
+
+	if err := p.deadLetters.AddDeadLetters(dlCtx, p.id, stats.Rejected); err != nil {
+		log.Printf("Error dead-lettering %d rows for run %d: %v", len(stats.Rejected), p.id, err)
+		p.Error(fmt.Errorf("dead-lettering %d rejected rows: %w", len(stats.Rejected), err))
+	}
+}
+
+// Error records a non-fatal error against the run.
+func (p *Progress) Error(err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if rerr := p.repo.AddRunError(ctx, p.id, err.Error()); rerr != nil {
+		log.Printf("Error recording run %d error: %v", p.id, rerr)
+	}
+}
+
+// Warning records a warning against the run, once however often it is reported.
+func (p *Progress) Warning(msg string) {
+	p.mu.Lock()
+	seen := p.warnings[msg]
+	if p.warnings == nil {
+		p.warnings = make(map[string]bool)
+	}
+	p.warnings[msg] = true
+	p.mu.Unlock()
+
+	if seen {
+		return
+	}
+	log.Printf("Job %d warning: %s", p.id, msg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.repo.AddRunWarning(ctx, p.id, msg); err != nil {
+		log.Printf("Error recording run %d warning: %v", p.id, err)
+	}
+}
+
+// Totals returns the batches, rows, overflowed values, failed rows and the
+// inserted/updated/unchanged split so far.
+func (p *Progress) Totals() db.RunProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.totals
+}
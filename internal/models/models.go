@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -14,36 +15,41 @@ type Setting struct {
 }
 
 type Company struct {
-	Ticker    string    `json:"ticker"`
-	Name      string    `json:"name"`
-	Sector    string    `json:"sector"`
-	Industry  string    `json:"industry"`
-	Active    bool      `json:"active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Ticker         string     `json:"ticker"`
+	Name           string     `json:"name"`
+	Sector         string     `json:"sector"`
+	Industry       string     `json:"industry"`
+	Active         bool       `json:"active"` // Eligible for today's screens
+	IsDelisted     bool       `json:"is_delisted"`
+	FirstPriceDate *time.Time `json:"first_price_date"`
+	DelistedDate   *time.Time `json:"delisted_date"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
 }
 
+// FinancialMetric is a row of financial_metrics. Metric columns are nullable since
+// Sharadar omits values that don't apply (or overflowed on ingest).
 type FinancialMetric struct {
-	ID              int             `json:"id"`
-	Ticker          string          `json:"ticker"`
-	Dimension       string          `json:"dimension"` // ARQ, MRQ, ARY, MRY, ART, MRT
-	DateKey         time.Time       `json:"date_key"`
-	ReportPeriod    time.Time       `json:"report_period"`
-	Revenue         decimal.Decimal `json:"revenue"`
-	NetIncome       decimal.Decimal `json:"net_income"`
-	EBITDA          decimal.Decimal `json:"ebitda"`
-	FCF             decimal.Decimal `json:"fcf"`
-	ROIC            decimal.Decimal `json:"roic"`
-	PERatio         decimal.Decimal `json:"pe_ratio"`
-	EVEBIT          decimal.Decimal `json:"ev_ebit"`
-	PBRatio         decimal.Decimal `json:"pb_ratio"`
-	DebtToEquity    decimal.Decimal `json:"debt_to_equity"`
-	MarketCap       decimal.Decimal `json:"market_cap"`
-	EnterpriseValue decimal.Decimal `json:"enterprise_value"`
-	Price           decimal.Decimal `json:"price"`
-	LastUpdated     *time.Time      `json:"last_updated"` // From Sharadar API
-	CreatedAt       time.Time       `json:"created_at"`
-	UpdatedAt       time.Time       `json:"updated_at"`
+	ID              int                 `json:"id"`
+	Ticker          string              `json:"ticker"`
+	Dimension       string              `json:"dimension"` // ARQ, MRQ, ARY, MRY, ART, MRT
+	DateKey         time.Time           `json:"date_key"`  // SEC filing date (when the data became public)
+	ReportPeriod    time.Time           `json:"report_period"`
+	Revenue         decimal.NullDecimal `json:"revenue"`
+	NetIncome       decimal.NullDecimal `json:"net_income"`
+	EBITDA          decimal.NullDecimal `json:"ebitda"`
+	FCF             decimal.NullDecimal `json:"fcf"`
+	ROIC            decimal.NullDecimal `json:"roic"`
+	PERatio         decimal.NullDecimal `json:"pe_ratio"`
+	EVEBIT          decimal.NullDecimal `json:"ev_ebit"`
+	PBRatio         decimal.NullDecimal `json:"pb_ratio"`
+	DebtToEquity    decimal.NullDecimal `json:"debt_to_equity"`
+	MarketCap       decimal.NullDecimal `json:"market_cap"`
+	EnterpriseValue decimal.NullDecimal `json:"enterprise_value"`
+	Price           decimal.NullDecimal `json:"price"`
+	LastUpdated     *time.Time          `json:"last_updated"` // From Sharadar API
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
 }
 
 type PortfolioHolding struct {
@@ -52,7 +58,7 @@ type PortfolioHolding struct {
 	SharesOwned  decimal.Decimal `json:"shares_owned"`
 	CostBasis    decimal.Decimal `json:"cost_basis"`
 	TargetWeight decimal.Decimal `json:"target_weight"`
-	AcquiredDate time.Time       `json:"acquired_date"`
+	AcquiredDate *time.Time      `json:"acquired_date"`
 	CreatedAt    time.Time       `json:"created_at"`
 	UpdatedAt    time.Time       `json:"updated_at"`
 }
@@ -84,6 +90,7 @@ type Benchmark struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// BenchmarkPrice is a benchmark's SHARADAR/SFP price, stored in fund_prices.
 type BenchmarkPrice struct {
 	ID          int             `json:"id"`
 	Ticker      string          `json:"ticker"`
@@ -93,8 +100,81 @@ type BenchmarkPrice struct {
 	Low         decimal.Decimal `json:"low"`
 	Close       decimal.Decimal `json:"close"`
 	Volume      int64           `json:"volume"`
-	Dividends   decimal.Decimal `json:"dividends"`
+	CloseAdj    decimal.Decimal `json:"close_adj"`
 	CloseUnadj  decimal.Decimal `json:"close_unadj"`
 	LastUpdated *time.Time      `json:"last_updated"`
 	CreatedAt   time.Time       `json:"created_at"`
 }
+
+// Recommendation is a single pick produced by a screening strategy.
+type Recommendation struct {
+	Ticker    string          `json:"ticker"`
+	Name      string          `json:"name"`
+	Sector    string          `json:"sector"`
+	DateKey   time.Time       `json:"date_key"` // Filing date of the metrics used
+	ROIC      decimal.Decimal `json:"roic"`
+	EVEBIT    decimal.Decimal `json:"ev_ebit"`
+	MarketCap decimal.Decimal `json:"market_cap"`
+	Price     decimal.Decimal `json:"price"`
+	Ranks     []RankComponent `json:"ranks"`  // Per-metric ranks that make up the score
+	Score     int             `json:"score"`  // Sum of component ranks (lower is better)
+	Rank      int             `json:"rank"`   // Position in the final screen (1 = best)
+	Weight    decimal.Decimal `json:"weight"` // Proposed portfolio weight (0-1)
+}
+
+// RankComponent is one metric's contribution to a recommendation's score.
+type RankComponent struct {
+	Metric string `json:"metric"`
+	Rank   int    `json:"rank"`
+}
+
+// Ingest run statuses.
+const (
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+	RunCancelled = "cancelled"
+)
+
+// IngestRun is one ingestion step recorded in the ingest_runs table. Background
+// jobs are runs too; a job ID is its run ID.
+type IngestRun struct {
+	ID               int64           `json:"id"`
+	Kind             string          `json:"kind"`     // tickers, fundamentals, daily, prices, benchmarks, sp500, dead_letters
+	Endpoint         string          `json:"endpoint"` // API path, or "scheduler"
+	Params           json.RawMessage `json:"params" swaggertype:"object"`
+	Status           string          `json:"status"`
+	Message          string          `json:"message,omitempty"`
+	Since            *time.Time      `json:"since"` // Earliest incremental watermark, nil = all history
+	WatermarkGroups  int             `json:"watermark_groups"`
+	BatchesDone      int             `json:"batches_done"`
+	RowsUpserted     int64           `json:"rows_upserted"`
+	ValuesOverflowed int             `json:"values_overflowed"` // Stored as NULL because they overflow their column
+	RowsFailed       int             `json:"rows_failed"`       // Rows in failed database batches
+	RowsInserted     int64           `json:"rows_inserted"`     // New rows, counted by the COPY path only
+	RowsUpdated      int64           `json:"rows_updated"`      // Rows with changed values, COPY path only
+	RowsUnchanged    int64           `json:"rows_unchanged"`    // Rows already stored as fetched, COPY path only
+	ErrorCount       int             `json:"error_count"`
+	Errors           []string        `json:"errors"`   // First errors only
+	Warnings         []string        `json:"warnings"` // Schema drift, each recorded once
+	StartedAt        time.Time       `json:"started_at"`
+	FinishedAt       *time.Time      `json:"finished_at"`
+	Elapsed          string          `json:"elapsed"`
+}
+
+// DeadLetter is a value or row that ingestion could not store as fetched, kept in
+// the ingest_dead_letters table until a retry succeeds.
+type DeadLetter struct {
+	ID             int64           `json:"id"`
+	RunID          *int64          `json:"run_id"`
+	Table          string          `json:"table"`
+	Ticker         string          `json:"ticker"`
+	Field          string          `json:"field,omitempty"` // Overflowing column, empty for failed batches
+	Reason         string          `json:"reason"`
+	Row            json.RawMessage `json:"row" swaggertype:"object"` // The row as fetched
+	RetryCount     int             `json:"retry_count"`
+	LastRetryError string          `json:"last_retry_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	LastRetryAt    *time.Time      `json:"last_retry_at"`
+	ResolvedAt     *time.Time      `json:"resolved_at"`
+}
@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+)
+
+// batchSink stores the batches of a fetch stream.
+type batchSink[T any] struct {
+	name        string                                             // What is stored, for messages, e.g. "daily prices"
+	upsert      func(context.Context, []T) (db.UpsertStats, error) // Stores one batch
+	filter      func(context.Context, []T) []T                     // Drops rows before upserting, nil keeps all
+	stopOnError bool                                               // Stop at the first fetch error instead of trying the rest
+}
+
+// drain upserts batches as they arrive, up to maxDBParallel at once, until the
+// stream closes. Failed upserts are reported to progress and skipped. A fetch
+// error is reported too; the remaining batches still run unless stopOnError is
+// set, in which case the caller must cancel the fetch. Returns the rows
+// upserted and the last fetch error.
+func (s batchSink[T]) drain(ctx context.Context, batches <-chan ingest.Batch[T], progress Progress) (int, error) {
+	var total atomic.Int64
+	var wg sync.WaitGroup
+	var fetchErr error
+	sem := make(chan struct{}, maxDBParallel)
+
+	for batch := range batches {
+		if batch.Error != nil {
+			fetchErr = batch.Error
+			log.Printf("Error fetching %s: %v", s.name, batch.Error)
+			progress.Error(batch.Error)
+			if s.stopOnError {
+				break
+			}
+			continue // Don't stop - try other batches
+		}
+
+		rows := batch.Rows
+		if s.filter != nil {
+			rows = s.filter(ctx, rows)
+		}
+		if len(rows) == 0 {
+			progress.BatchDone(db.UpsertStats{})
+			continue
+		}
+
+		sem <- struct{}{} // Acquire slot (blocks if maxDBParallel upserts running)
+
+		wg.Add(1)
+		go func(rows []T) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			stats, _ := s.upsert(ctx, rows)
+			for _, err := range stats.Errors {
+				log.Printf("Error upserting %s: %v", s.name, err)
+				progress.Error(fmt.Errorf("upserting %s: %w", s.name, err))
+			}
+			total.Add(int64(stats.Rows))
+			progress.BatchDone(stats)
+			log.Printf("Upserted %d %s", stats.Rows, s.name)
+		}(rows)
+	}
+
+	wg.Wait()
+	return int(total.Load()), fetchErr
+}
+
+// onlyTickers returns a filter keeping rows for the given tickers, for exports
+// that cover every ticker in a table rather than the companies ingested.
+func onlyTickers[T any](tickers []string, ticker func(T) string) func(context.Context, []T) []T {
+	keep := make(map[string]bool, len(tickers))
+	for _, t := range tickers {
+		keep[t] = true
+	}
+	return func(_ context.Context, rows []T) []T {
+		kept := make([]T, 0, len(rows))
+		for _, row := range rows {
+			if keep[ticker(row)] {
+				kept = append(kept, row)
+			}
+		}
+		return kept
+	}
+}
+
+// knownCompanies returns a filter keeping rows whose ticker is in the companies
+// table, for tables that reference it. Lookups are cached for the fetch.
+func knownCompanies[T any](repo *db.Repository, ticker func(T) string) func(context.Context, []T) []T {
+	known := make(map[string]bool)
+	return func(ctx context.Context, rows []T) []T {
+		kept := make([]T, 0, len(rows))
+		for _, row := range rows {
+			t := ticker(row)
+			exists, ok := known[t]
+			if !ok {
+				var err error
+				exists, err = repo.CompanyExists(ctx, t)
+				if err != nil {
+					continue // Not cached, so the next batch asks again
+				}
+				known[t] = exists
+			}
+			if exists {
+				kept = append(kept, row)
+			}
+		}
+		return kept
+	}
+}
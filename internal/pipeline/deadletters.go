@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+)
+
+// RetryResult summarises a dead-letter retry.
+type RetryResult struct {
+	Retried  int `json:"retried"`
+	Resolved int `json:"resolved"` // Stored as fetched
+	Failed   int `json:"failed"`   // Rejected again, see the letter's last_retry_error
+}
+
+// RetryDeadLetters upserts unresolved dead-lettered rows again from the rows as
+// fetched. Column limits are read from the schema on each upsert, so rows rejected
+// for overflow are stored once a migration widens their column. A row is never
+// stored over one updated since it was fetched. Letters whose rows are stored, or
+// skipped as stale, are resolved; the rest record the new reason.
+func (p *Pipeline) RetryDeadLetters(ctx context.Context, deadLetters *db.DeadLetterRepository, filter db.DeadLetterFilter, progress Progress) (RetryResult, error) {
+	var result RetryResult
+
+	letters, err := deadLetters.ListDeadLetters(ctx, filter)
+	if err != nil {
+		return result, err
+	}
+
+	byTable := make(map[string][]models.DeadLetter)
+	for _, l := range letters {
+		byTable[l.Table] = append(byTable[l.Table], l)
+	}
+
+	for table, tableLetters := range byTable {
+		reasons, stats, err := p.retryTable(ctx, table, tableLetters)
+		if err != nil {
+			return result, fmt.Errorf("retrying %s dead letters: %w", table, err)
+		}
+		stats.Rejected = nil // Recorded on the letters below rather than as new ones
+		progress.BatchDone(stats)
+
+		var resolved []int64
+		for i, l := range tableLetters {
+			reason, rejected := reasons[i]
+			if !rejected {
+				resolved = append(resolved, l.ID)
+				continue
+			}
+			result.Failed++
+			if err := deadLetters.RetryFailed(ctx, l.ID, reason); err != nil {
+				return result, err
+			}
+		}
+
+		if err := deadLetters.ResolveDeadLetters(ctx, resolved); err != nil {
+			return result, err
+		}
+		result.Resolved += len(resolved)
+		result.Retried += len(tableLetters)
+	}
+
+	log.Printf("Retried %d dead letters: %d resolved, %d rejected again", result.Retried, result.Resolved, result.Failed)
+	if result.Failed > 0 {
+		progress.Warning(fmt.Sprintf("%d dead letters rejected again", result.Failed))
+	}
+
+	return result, nil
+}
+
+// retryTable stores one table's dead-lettered rows and returns the rejection
+// reason for each letter (by index) that failed again.
+func (p *Pipeline) retryTable(ctx context.Context, table string, letters []models.DeadLetter) (map[int]string, db.UpsertStats, error) {
+	switch table {
+	case "financial_metrics":
+		return retryRows(ctx, letters, func(r ingest.SF1Row) string {
+			return r.Ticker + " " + r.DateKey.Format("2006-01-02") + " " + r.Dimension
+		}, p.repo.RestoreFinancialMetrics)
+	case "daily_prices":
+		return retryRows(ctx, letters, func(r ingest.DailyRow) string {
+			return r.Ticker + " " + r.Date.Format("2006-01-02")
+		}, p.repo.RestoreDailyPrices)
+	case "equity_prices":
+		return retryRows(ctx, letters, priceKey, p.repo.RestoreEquityPrices)
+	case "fund_prices":
+		return retryRows(ctx, letters, priceKey, p.repo.RestoreFundPrices)
+	default:
+		reasons := make(map[int]string, len(letters))
+		for i := range letters {
+			reasons[i] = fmt.Sprintf("cannot retry rows for table %q", table)
+		}
+		return reasons, db.UpsertStats{}, nil
+	}
+}
+
+// priceKey is the conflict key of equity_prices and fund_prices.
+func priceKey(r ingest.PriceRow) string {
+	return r.Ticker + " " + r.Date.Format("2006-01-02")
+}
+
+// retryRows upserts one row per conflict key. A row can be dead-lettered more
+// than once, for each overflowing field or again by a later run, and ON CONFLICT
+// cannot update a row twice in one statement. Letters sharing a key are resolved
+// or fail together with the latest fetch of the row.
+func retryRows[T any](ctx context.Context, letters []models.DeadLetter, key func(T) string, upsert func(context.Context, []T) (db.UpsertStats, error)) (map[int]string, db.UpsertStats, error) {
+	var rows []T
+	var members [][]int // Letter indexes of each row
+	byKey := make(map[string]int)
+
+	for i, l := range letters {
+		var row T
+		if err := json.Unmarshal(l.Row, &row); err != nil {
+			return nil, db.UpsertStats{}, fmt.Errorf("decoding dead letter %d: %w", l.ID, err)
+		}
+
+		k := key(row)
+		if j, ok := byKey[k]; ok {
+			rows[j] = row // Letters are listed oldest first
+			members[j] = append(members[j], i)
+			continue
+		}
+		byKey[k] = len(rows)
+		rows = append(rows, row)
+		members = append(members, []int{i})
+	}
+
+	stats, err := upsert(ctx, rows)
+
+	// Failed batches are reported through Rejected; an error without rejections
+	// means nothing was attempted
+	if err != nil && len(stats.Rejected) == 0 {
+		return nil, stats, err
+	}
+
+	reasons := make(map[int]string)
+	for _, rej := range stats.Rejected {
+		for _, i := range members[rej.Index] {
+			if _, ok := reasons[i]; !ok {
+				reasons[i] = rej.Reason
+			}
+		}
+	}
+
+	return reasons, stats, nil
+}
@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+func TestRetryRowsGroupsByKey(t *testing.T) {
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	letter := func(id int64, ticker, close string) models.DeadLetter {
+		c := decimal.RequireFromString(close)
+		row, err := json.Marshal(ingest.PriceRow{Ticker: ticker, Date: date, Close: &c})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return models.DeadLetter{ID: id, Table: "equity_prices", Ticker: ticker, Row: row}
+	}
+	letters := []models.DeadLetter{
+		letter(1, "AAPL", "185.1"),
+		letter(2, "MSFT", "370.9"),
+		letter(3, "AAPL", "185.6"), // Same row, fetched again later
+	}
+
+	var upserted []ingest.PriceRow
+	upsert := func(_ context.Context, rows []ingest.PriceRow) (db.UpsertStats, error) {
+		upserted = rows
+		return db.UpsertStats{Rows: 1, Rejected: []db.RejectedRow{{Index: 0, Reason: "value overflows close"}}}, nil
+	}
+
+	reasons, _, err := retryRows(context.Background(), letters, priceKey, upsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(upserted) != 2 || upserted[0].Ticker != "AAPL" || upserted[0].Close.String() != "185.6" {
+		t.Errorf("upserted %+v, want the latest AAPL row and MSFT once each", upserted)
+	}
+	if reasons[0] != "value overflows close" || reasons[2] != "value overflows close" {
+		t.Errorf("reasons = %v, want both AAPL letters rejected", reasons)
+	}
+	if _, ok := reasons[1]; ok {
+		t.Errorf("MSFT letter rejected: %q", reasons[1])
+	}
+}
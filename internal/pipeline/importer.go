@@ -0,0 +1,168 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+)
+
+// ImportFile loads a Sharadar CSV or zipped CSV file into the database through the
+// same upserts as the API steps. table is one of ingest.FileTables; fundamentals
+// and equity prices reference companies, so TICKERS should be imported first.
+// Their rows for tickers not in the companies table are skipped; SFP fund prices
+// are kept whatever the ticker.
+func (p *Pipeline) ImportFile(ctx context.Context, table, path string, progress Progress) (int, error) {
+	table = strings.ToUpper(table)
+
+	switch table {
+	case "TICKERS":
+		// Small enough to upsert in one go; the file covers every Sharadar table
+		var rows []ingest.TickerRow
+		_, err := ingest.ReadFile(ctx, path, func(chunk *ingest.Response) error {
+			ingest.FilterRows(chunk, "table", "SF1")
+			parsed, err := ingest.ParseTickers(chunk)
+			rows = append(rows, parsed...)
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+		count, err := p.repo.UpsertCompanies(ctx, rows)
+		if err != nil {
+			return 0, fmt.Errorf("upserting companies: %w", err)
+		}
+		progress.BatchDone(db.UpsertStats{Rows: count})
+		return count, nil
+
+	case "SP500":
+		// Upserted in one call so the current constituents are replaced as a set
+		var rows []ingest.SP500Row
+		_, err := ingest.ReadFile(ctx, path, func(chunk *ingest.Response) error {
+			parsed, err := ingest.ParseSP500(chunk)
+			rows = append(rows, parsed...)
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+		count, err := p.repo.UpsertSP500Membership(ctx, rows)
+		if err != nil {
+			return 0, fmt.Errorf("upserting SP500 membership: %w", err)
+		}
+		progress.BatchDone(db.UpsertStats{Rows: count})
+		return count, nil
+
+	case "SF1":
+		return p.importChunks(ctx, path, progress, func(ctx context.Context, chunk *ingest.Response, known func(string) bool) (db.UpsertStats, error) {
+			rows, err := ingest.ParseSF1(chunk)
+			if err != nil {
+				return db.UpsertStats{}, err
+			}
+			return p.repo.CopyFinancialMetrics(ctx, keepKnown(rows, func(r ingest.SF1Row) string { return r.Ticker }, known))
+		})
+
+	case "DAILY":
+		return p.importChunks(ctx, path, progress, func(ctx context.Context, chunk *ingest.Response, known func(string) bool) (db.UpsertStats, error) {
+			rows, err := ingest.ParseDaily(chunk)
+			if err != nil {
+				return db.UpsertStats{}, err
+			}
+			return p.repo.CopyDailyPrices(ctx, keepKnown(rows, func(r ingest.DailyRow) string { return r.Ticker }, known))
+		})
+
+	case "SEP":
+		return p.importChunks(ctx, path, progress, func(ctx context.Context, chunk *ingest.Response, known func(string) bool) (db.UpsertStats, error) {
+			rows, err := ingest.ParsePrices(chunk)
+			if err != nil {
+				return db.UpsertStats{}, err
+			}
+			return p.repo.CopyEquityPrices(ctx, keepKnown(rows, func(r ingest.PriceRow) string { return r.Ticker }, known))
+		})
+
+	case "SFP":
+		// Funds aren't in companies, so every row is kept
+		return p.readChunks(ctx, path, progress, func(ctx context.Context, chunk *ingest.Response) (db.UpsertStats, error) {
+			rows, err := ingest.ParsePrices(chunk)
+			if err != nil {
+				return db.UpsertStats{}, err
+			}
+			return p.repo.CopyFundPrices(ctx, rows)
+		})
+	}
+
+	return 0, fmt.Errorf("unsupported table %q, expected one of %s", table, strings.Join(ingest.FileTables, ", "))
+}
+
+// importChunks upserts a large file of company rows chunk by chunk. upsert gets a
+// lookup of the tickers in the companies table.
+func (p *Pipeline) importChunks(ctx context.Context, path string, progress Progress,
+	upsert func(ctx context.Context, chunk *ingest.Response, known func(string) bool) (db.UpsertStats, error)) (int, error) {
+	if err := p.CheckCompanies(ctx); err != nil {
+		return 0, err
+	}
+
+	tickers, err := p.repo.GetAllTickers(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("getting tickers: %w", err)
+	}
+	companies := make(map[string]bool, len(tickers))
+	for _, t := range tickers {
+		companies[t] = true
+	}
+	skipped := make(map[string]bool)
+	known := func(ticker string) bool {
+		if !companies[ticker] {
+			skipped[ticker] = true
+			return false
+		}
+		return true
+	}
+
+	total, err := p.readChunks(ctx, path, progress, func(ctx context.Context, chunk *ingest.Response) (db.UpsertStats, error) {
+		return upsert(ctx, chunk, known)
+	})
+
+	if len(skipped) > 0 {
+		log.Printf("Skipped rows for %d tickers not in companies (import TICKERS first)", len(skipped))
+	}
+
+	return total, err
+}
+
+// keepKnown filters rows in place to those whose ticker is known.
+func keepKnown[T any](rows []T, ticker func(T) string, known func(string) bool) []T {
+	kept := rows[:0]
+	for _, row := range rows {
+		if known(ticker(row)) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// readChunks upserts a large file chunk by chunk, reporting each chunk's stats
+// and failed batches to progress.
+func (p *Pipeline) readChunks(ctx context.Context, path string, progress Progress,
+	upsert func(ctx context.Context, chunk *ingest.Response) (db.UpsertStats, error)) (int, error) {
+	total := 0
+	read, err := ingest.ReadFile(ctx, path, func(chunk *ingest.Response) error {
+		stats, err := upsert(ctx, chunk)
+		for _, e := range stats.Errors {
+			progress.Error(e)
+		}
+		total += stats.Rows
+		progress.BatchDone(stats)
+		if err != nil && stats.Rows == 0 && len(stats.Rejected) == 0 {
+			return err // Nothing was attempted, e.g. the database is unreachable
+		}
+		return nil
+	})
+
+	log.Printf("Imported %d of %d rows from %s", total, read, path)
+
+	return total, err
+}
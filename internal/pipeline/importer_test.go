@@ -0,0 +1,39 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+)
+
+func TestKeepKnown(t *testing.T) {
+	rows := []ingest.PriceRow{{Ticker: "AAPL"}, {Ticker: "SPY"}, {Ticker: "MSFT"}, {Ticker: "SPY"}}
+	companies := map[string]bool{"AAPL": true, "MSFT": true}
+
+	var asked []string
+	kept := keepKnown(rows, func(r ingest.PriceRow) string { return r.Ticker }, func(ticker string) bool {
+		asked = append(asked, ticker)
+		return companies[ticker]
+	})
+
+	var tickers []string
+	for _, r := range kept {
+		tickers = append(tickers, r.Ticker)
+	}
+	if fmt.Sprint(tickers) != "[AAPL MSFT]" {
+		t.Errorf("kept %v, want [AAPL MSFT]", tickers)
+	}
+	if len(asked) != len(rows) {
+		t.Errorf("known called %d times, want once per row", len(asked))
+	}
+}
+
+func TestImportFileUnsupportedTable(t *testing.T) {
+	// Rejected before the database or the file is touched
+	p := &Pipeline{}
+	if _, err := p.ImportFile(context.Background(), "sf3", "SHARADAR_SF3.csv", nil); err == nil {
+		t.Error("expected an error for an unsupported table")
+	}
+}
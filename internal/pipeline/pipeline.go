@@ -0,0 +1,377 @@
+// Package pipeline fetches Sharadar tables and upserts them into the database.
+// Each step can run inside an HTTP request or as a background job.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+)
+
+// ErrNoCompanies is returned when a step needs the companies table populated first.
+var ErrNoCompanies = errors.New("no companies in database, run /admin/ingest/tickers first")
+
+// ErrNoBenchmarks is returned when no benchmarks are configured.
+var ErrNoBenchmarks = errors.New("no benchmarks configured in database")
+
+const (
+	maxAPIParallel = 5 // Concurrent API fetches
+	maxDBParallel  = 3 // Concurrent upserts
+)
+
+// Progress receives updates as a step runs. Implementations must be safe for
+// concurrent use since upserts run in parallel.
+type Progress interface {
+	Planned(groups []ingest.TickerGroup) // Watermark groups about to be fetched
+	BatchDone(stats db.UpsertStats)
+	Error(err error)
+	Warning(msg string) // Called again for the same warning by every batch it affects
+}
+
+// NoProgress discards progress updates.
+type NoProgress struct{}
+
+func (NoProgress) Planned([]ingest.TickerGroup) {}
+func (NoProgress) BatchDone(db.UpsertStats)     {}
+func (NoProgress) Error(error)                  {}
+func (NoProgress) Warning(string)               {}
+
+// reportDrift returns a context whose fetches pass schema drift to progress as warnings.
+func reportDrift(ctx context.Context, progress Progress) context.Context {
+	return ingest.WithDriftReporter(ctx, func(d ingest.SchemaDrift) {
+		for _, w := range d.Warnings() {
+			progress.Warning(w)
+		}
+	})
+}
+
+// Params select what a step fetches.
+type Params struct {
+	Tickers    []string `json:"tickers,omitempty"`    // Defaults to every company in the database
+	Dimensions []string `json:"dimensions,omitempty"` // SF1 only, defaults to ARQ,MRQ
+	Full       bool     `json:"full"`                 // Fetch all history instead of incrementally, by bulk export for every company
+}
+
+// Pipeline runs ingestion steps.
+type Pipeline struct {
+	source ingest.DataSource
+	repo   *db.Repository
+}
+
+// New creates a new pipeline fetching from source, such as an *ingest.Client.
+// source may be nil for a pipeline that only imports files.
+func New(source ingest.DataSource, repo *db.Repository) *Pipeline {
+	return &Pipeline{
+		source: source,
+		repo:   repo,
+	}
+}
+
+// ParseList splits a comma-separated query parameter such as a ticker list.
+func ParseList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	tickers := strings.Split(s, ",")
+	for i := range tickers {
+		tickers[i] = strings.TrimSpace(tickers[i])
+	}
+	return tickers
+}
+
+// Tickers fetches company metadata from SHARADAR/TICKERS (all tickers when none are given).
+func (p *Pipeline) Tickers(ctx context.Context, tickers []string, progress Progress) (int, error) {
+	ctx = reportDrift(ctx, progress)
+
+	rows, err := p.source.FetchTickers(ctx, tickers)
+	if err != nil {
+		return 0, fmt.Errorf("fetching tickers: %w", err)
+	}
+
+	log.Printf("Fetched %d tickers from API", len(rows))
+
+	count, err := p.repo.UpsertCompanies(ctx, rows)
+	if err != nil {
+		return 0, fmt.Errorf("upserting companies: %w", err)
+	}
+	progress.BatchDone(db.UpsertStats{Rows: count})
+
+	return count, nil
+}
+
+// Fundamentals fetches SHARADAR/SF1 for each dimension. Stops at the first fetch
+// error; failed upserts are reported to progress and skipped.
+func (p *Pipeline) Fundamentals(ctx context.Context, params Params, progress Progress) (int, error) {
+	ctx = reportDrift(ctx, progress)
+
+	if err := p.CheckCompanies(ctx); err != nil {
+		return 0, err
+	}
+
+	tickers, err := p.resolveTickers(ctx, params.Tickers)
+	if err != nil {
+		return 0, err
+	}
+
+	dimensions := params.Dimensions
+	if len(dimensions) == 0 {
+		dimensions = []string{"ARQ", "MRQ"}
+	}
+
+	log.Printf("Starting fundamentals ingestion (tickers: %d, dimensions: %v, full: %v)...", len(tickers), dimensions, params.Full)
+
+	total := 0
+	for _, dimension := range dimensions {
+		dimension = strings.TrimSpace(dimension)
+		if dimension == "" {
+			continue
+		}
+
+		// Incremental fetches start each ticker from its own watermark for this dimension
+		groups := []ingest.TickerGroup{{Tickers: tickers}}
+		if !params.Full {
+			marks, err := p.repo.GetWatermarks(ctx, "financial_metrics", dimension)
+			if err != nil {
+				return total, err
+			}
+			groups = groupByWatermark(tickers, marks)
+		}
+		progress.Planned(groups)
+
+		sink := batchSink[ingest.SF1Row]{
+			name:        fmt.Sprintf("metrics (%s)", dimension),
+			upsert:      p.repo.UpsertFinancialMetrics,
+			stopOnError: true,
+		}
+		if params.Full {
+			sink.upsert = p.repo.CopyFinancialMetrics
+		}
+
+		// Cancelled after draining so fetchers blocked on the channel exit after a fetch error
+		fetchCtx, cancel := context.WithCancel(ctx)
+		var batches <-chan ingest.SF1Batch
+		if exporter, ok := p.exporter(params); ok {
+			sink.filter = onlyTickers(tickers, func(r ingest.SF1Row) string { return r.Ticker })
+			batches = exporter.ExportSF1Stream(fetchCtx, dimension)
+		} else {
+			batches = p.source.FetchSF1Stream(fetchCtx, groups, dimension, maxAPIParallel)
+		}
+		count, fetchErr := sink.drain(ctx, batches, progress)
+		cancel()
+		total += count
+
+		if fetchErr != nil {
+			return total, fmt.Errorf("fetching SF1 (%s): %w", dimension, fetchErr)
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// Daily fetches SHARADAR/DAILY. Fetch errors are reported to progress and the
+// remaining batches still run; the step only fails if nothing was upserted.
+func (p *Pipeline) Daily(ctx context.Context, params Params, progress Progress) (int, error) {
+	ctx = reportDrift(ctx, progress)
+
+	tickers, err := p.resolveTickers(ctx, params.Tickers)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Starting daily price ingestion (tickers: %d, full: %v)...", len(tickers), params.Full)
+
+	groups, err := p.planPrices(ctx, "daily_prices", tickers, params.Full)
+	if err != nil {
+		return 0, err
+	}
+	progress.Planned(groups)
+
+	sink := batchSink[ingest.DailyRow]{name: "daily prices", upsert: p.repo.UpsertDailyPrices}
+	if params.Full {
+		sink.upsert = p.repo.CopyDailyPrices // Backfills are mostly new rows
+	}
+	// Filter to valid tickers only if we're fetching specific tickers (not all from DB)
+	if len(params.Tickers) > 0 {
+		sink.filter = knownCompanies(p.repo, func(r ingest.DailyRow) string { return r.Ticker })
+	}
+
+	var batches <-chan ingest.DailyBatch
+	if exporter, ok := p.exporter(params); ok {
+		sink.filter = onlyTickers(tickers, func(r ingest.DailyRow) string { return r.Ticker })
+		batches = exporter.ExportDailyStream(ctx)
+	} else {
+		batches = p.source.FetchDailyStream(ctx, groups, maxAPIParallel)
+	}
+
+	count, fetchErr := sink.drain(ctx, batches, progress)
+	if err := ctx.Err(); err != nil {
+		return count, err
+	}
+	if fetchErr != nil && count == 0 {
+		return 0, fmt.Errorf("fetching daily prices: %w", fetchErr)
+	}
+
+	return count, nil
+}
+
+// Prices fetches split-adjusted equity prices from SHARADAR/SEP into equity_prices,
+// which backtests price from. Errors are handled as in Daily.
+func (p *Pipeline) Prices(ctx context.Context, params Params, progress Progress) (int, error) {
+	ctx = reportDrift(ctx, progress)
+
+	tickers, err := p.resolveTickers(ctx, params.Tickers)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Starting SEP price ingestion (tickers: %d, full: %v)...", len(tickers), params.Full)
+
+	groups, err := p.planPrices(ctx, "equity_prices", tickers, params.Full)
+	if err != nil {
+		return 0, err
+	}
+	progress.Planned(groups)
+
+	sink := batchSink[ingest.PriceRow]{name: "equity prices", upsert: p.repo.CopyEquityPrices}
+	// equity_prices references companies, so requested tickers outside it are dropped
+	if len(params.Tickers) > 0 {
+		sink.filter = knownCompanies(p.repo, func(r ingest.PriceRow) string { return r.Ticker })
+	}
+
+	count, fetchErr := sink.drain(ctx, p.source.FetchSEPStream(ctx, groups, maxAPIParallel), progress)
+	if err := ctx.Err(); err != nil {
+		return count, err
+	}
+	if fetchErr != nil && count == 0 {
+		return 0, fmt.Errorf("fetching equity prices: %w", fetchErr)
+	}
+
+	return count, nil
+}
+
+// Benchmarks fetches SHARADAR/SFP fund prices for the configured benchmarks into fund_prices.
+func (p *Pipeline) Benchmarks(ctx context.Context, params Params, progress Progress) (int, error) {
+	ctx = reportDrift(ctx, progress)
+
+	tickers, err := p.repo.GetBenchmarkTickers(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("getting benchmark tickers: %w", err)
+	}
+	if len(tickers) == 0 {
+		return 0, ErrNoBenchmarks
+	}
+
+	log.Printf("Starting benchmark ingestion (tickers: %v, full: %v)...", tickers, params.Full)
+
+	// Incremental fetches start each benchmark from its own watermark
+	groups := []ingest.TickerGroup{{Tickers: tickers}}
+	if !params.Full {
+		marks, err := p.repo.GetWatermarks(ctx, "fund_prices", "")
+		if err != nil {
+			return 0, err
+		}
+		groups = groupByWatermark(tickers, marks)
+	}
+	progress.Planned(groups)
+
+	total := 0
+	for _, group := range groups {
+		// Benchmarks are ETFs, which SHARADAR/SFP covers and SHARADAR/DAILY doesn't
+		rows, err := p.source.FetchSFP(ctx, group.Tickers, group.Since)
+		if err != nil {
+			return total, fmt.Errorf("fetching benchmark prices: %w", err)
+		}
+
+		log.Printf("Fetched %d benchmark price rows", len(rows))
+
+		stats, err := p.repo.CopyFundPrices(ctx, rows)
+		if err != nil {
+			return total, fmt.Errorf("upserting benchmark prices: %w", err)
+		}
+		for _, err := range stats.Errors {
+			progress.Error(fmt.Errorf("upserting benchmark prices: %w", err))
+		}
+		total += stats.Rows
+		progress.BatchDone(stats)
+	}
+
+	return total, nil
+}
+
+// SP500 fetches the S&P 500 membership history and current constituents.
+func (p *Pipeline) SP500(ctx context.Context, progress Progress) (int, error) {
+	rows, err := p.source.FetchSP500History(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("fetching SP500 history: %w", err)
+	}
+
+	log.Printf("Fetched %d SP500 membership rows", len(rows))
+
+	count, err := p.repo.UpsertSP500Membership(ctx, rows)
+	if err != nil {
+		return 0, fmt.Errorf("upserting SP500 membership: %w", err)
+	}
+	progress.BatchDone(db.UpsertStats{Rows: count})
+
+	return count, nil
+}
+
+// Usage returns today's API usage against the source's daily quotas. ok is false
+// for sources without a quota, such as a replay.
+func (p *Pipeline) Usage(ctx context.Context) (usage ingest.Usage, ok bool) {
+	reporter, ok := p.source.(ingest.UsageReporter)
+	if !ok {
+		return ingest.Usage{}, false
+	}
+	return reporter.Usage(ctx), true
+}
+
+// exporter returns the source as an Exporter when params ask for the full history
+// of every company, which a bulk export downloads faster than paging. A source
+// recording or replaying a cassette pages instead, so the cassette stays complete.
+func (p *Pipeline) exporter(params Params) (ingest.Exporter, bool) {
+	if !params.Full || len(params.Tickers) > 0 {
+		return nil, false
+	}
+	exporter, ok := p.source.(ingest.Exporter)
+	if !ok || !exporter.CanExport() {
+		return nil, false
+	}
+	return exporter, true
+}
+
+// CheckCompanies returns ErrNoCompanies if the companies table is empty.
+func (p *Pipeline) CheckCompanies(ctx context.Context) error {
+	count, err := p.repo.GetCompanyCount(ctx)
+	if err != nil {
+		return fmt.Errorf("checking companies: %w", err)
+	}
+	if count == 0 {
+		return ErrNoCompanies
+	}
+	return nil
+}
+
+// resolveTickers defaults an empty ticker list to every company in the database.
+func (p *Pipeline) resolveTickers(ctx context.Context, tickers []string) ([]string, error) {
+	if len(tickers) > 0 {
+		return tickers, nil
+	}
+
+	tickers, err := p.repo.GetAllTickers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting tickers: %w", err)
+	}
+	if len(tickers) == 0 {
+		return nil, ErrNoCompanies
+	}
+	return tickers, nil
+}
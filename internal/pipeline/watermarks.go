@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/mauv0809/crispy-broccoli/internal/ingest"
+	"github.com/mauv0809/crispy-broccoli/internal/models"
+)
+
+// watermarkTolerance merges watermarks this close together into one request group.
+// Fetching a ticker from a little before its own watermark only re-reads rows the
+// upsert already has, and keeps the request count close to a single global watermark.
+const watermarkTolerance = 7 * 24 * time.Hour
+
+// groupByWatermark groups tickers that share a watermark (within watermarkTolerance)
+// so each group can be fetched with one since date, the earliest in the group.
+// Tickers without a watermark are new and form a group fetched from the beginning.
+func groupByWatermark(tickers []string, marks map[string]time.Time) []ingest.TickerGroup {
+	var backfill []string
+	var known []string
+	for _, t := range tickers {
+		if _, ok := marks[t]; ok {
+			known = append(known, t)
+		} else {
+			backfill = append(backfill, t)
+		}
+	}
+
+	var groups []ingest.TickerGroup
+	if len(backfill) > 0 {
+		groups = append(groups, ingest.TickerGroup{Tickers: backfill})
+	}
+
+	sort.SliceStable(known, func(i, j int) bool {
+		return marks[known[i]].Before(marks[known[j]])
+	})
+
+	for _, t := range known {
+		mark := marks[t]
+		last := len(groups) - 1
+		if last >= 0 && !groups[last].Since.IsZero() && mark.Sub(groups[last].Since) <= watermarkTolerance {
+			groups[last].Tickers = append(groups[last].Tickers, t)
+			continue
+		}
+		groups = append(groups, ingest.TickerGroup{Tickers: []string{t}, Since: mark})
+	}
+
+	log.Printf("Grouped %d tickers into %d watermark groups (%d new tickers to backfill)", len(tickers), len(groups), len(backfill))
+
+	return groups
+}
+
+// dropFinishedDelisted removes delisted tickers whose prices already reach their
+// delisting date, since no new rows can arrive for them.
+func dropFinishedDelisted(tickers []string, marks map[string]time.Time, companies map[string]models.Company) []string {
+	kept := tickers[:0:0]
+	for _, t := range tickers {
+		c, ok := companies[t]
+		mark, hasMark := marks[t]
+		if ok && hasMark && c.IsDelisted && c.DelistedDate != nil && !mark.Before(*c.DelistedDate) {
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return kept
+}
+
+// planPrices returns the groups a price step fetches: every ticker from the
+// beginning for a full fetch, otherwise each ticker from its own watermark in
+// table, skipping delisted tickers that are complete.
+func (p *Pipeline) planPrices(ctx context.Context, table string, tickers []string, full bool) ([]ingest.TickerGroup, error) {
+	if full {
+		return []ingest.TickerGroup{{Tickers: tickers}}, nil
+	}
+
+	marks, err := p.repo.GetWatermarks(ctx, table, "")
+	if err != nil {
+		return nil, err
+	}
+	companies, err := p.repo.GetCompanies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return groupByWatermark(dropFinishedDelisted(tickers, marks, companies), marks), nil
+}
@@ -0,0 +1,46 @@
+package pipeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGroupByWatermark(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+	marks := map[string]time.Time{
+		"A": day(1),
+		"B": day(8),  // Exactly the tolerance after A
+		"C": day(9),  // Past it, so starts a group
+		"D": day(15), // Within C's group
+		"F": day(16), // Measured from the group's start, not from D
+		"G": day(24),
+	}
+
+	groups := groupByWatermark([]string{"G", "F", "E", "D", "C", "B", "A"}, marks)
+
+	want := []struct {
+		tickers string
+		since   time.Time
+	}{
+		{"[E]", time.Time{}}, // No watermark, fetched from the beginning
+		{"[A B]", day(1)},
+		{"[C D F]", day(9)},
+		{"[G]", day(24)},
+	}
+
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups %v, want %d", len(groups), groups, len(want))
+	}
+	for i, w := range want {
+		if got := fmt.Sprint(groups[i].Tickers); got != w.tickers || !groups[i].Since.Equal(w.since) {
+			t.Errorf("group %d = %s since %s, want %s since %s", i+1, got, groups[i].Since.Format("2006-01-02"), w.tickers, w.since.Format("2006-01-02"))
+		}
+	}
+
+	if groups := groupByWatermark(nil, marks); len(groups) != 0 {
+		t.Errorf("no tickers: got %d groups", len(groups))
+	}
+}
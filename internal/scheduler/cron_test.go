@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",          // Four fields
+		"60 * * * *",       // Minute out of range
+		"0 24 * * *",       // Hour out of range
+		"0 0 0 * *",        // Day of month starts at 1
+		"0 0 * 13 *",       // Month out of range
+		"0 0 * * 8",        // Day of week out of range
+		"*/0 * * * *",      // Zero step
+		"5-1 * * * *",      // Backwards range
+		"a * * * *",        // Not a number
+		"0 19 * * 1-5/x",   // Bad step
+		"0 19 * * mon-fri", // Names aren't supported
+	} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("ParseSchedule(%q): expected an error", expr)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, ny)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	tests := []struct {
+		expr, from, want string
+	}{
+		{"0 19 * * 1-5", "2024-03-04 18:00", "2024-03-04 19:00"}, // Later the same day
+		{"0 19 * * 1-5", "2024-03-04 19:00", "2024-03-05 19:00"}, // Strictly after
+		{"0 19 * * 1-5", "2024-03-01 20:00", "2024-03-04 19:00"}, // Friday night to Monday
+		{"*/15 * * * *", "2024-03-04 10:07", "2024-03-04 10:15"},
+		{"5/20 9 * * *", "2024-03-04 09:26", "2024-03-04 09:45"}, // 5, 25, 45
+		{"0 0 1 * 1", "2024-03-02 12:00", "2024-03-04 00:00"},    // Day of month or Monday
+		{"0 0 * * 7", "2024-03-04 12:00", "2024-03-10 00:00"},    // 7 is Sunday
+		{"30 2 * * *", "2024-03-09 03:00", "2024-03-11 02:30"},   // 2:30 doesn't exist on DST day
+	}
+
+	for _, tt := range tests {
+		sched, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tt.expr, err)
+		}
+		if got := sched.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("%q after %s = %s, want %s", tt.expr, tt.from, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+
+	impossible, err := ParseSchedule("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := impossible.Next(at("2024-01-01 00:00")); !got.IsZero() {
+		t.Errorf("31 February matched %s", got)
+	}
+}
+
+func TestNextTradingRun(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, ny)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	sched, err := ParseSchedule(DefaultSchedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, want, why string
+	}{
+		{"2024-03-05 08:00", "2024-03-05 19:00", "ordinary Tuesday"},
+		{"2024-03-28 20:00", "2024-04-01 19:00", "Good Friday, then the weekend"},
+		{"2024-01-12 20:00", "2024-01-16 19:00", "weekend, then Martin Luther King Jr. Day"},
+		{"2021-12-30 20:00", "2021-12-31 19:00", "Friday before a Saturday New Year's Day trades"},
+		{"2022-06-17 20:00", "2022-06-21 19:00", "weekend, then Juneteenth observed Monday"},
+		{"2020-12-31 20:00", "2021-01-04 19:00", "New Year's Day on a Friday, then the weekend"},
+		{"2023-11-22 20:00", "2023-11-24 19:00", "Thanksgiving"},
+	}
+
+	for _, tt := range tests {
+		if got := NextTradingRun(sched, at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("after %s = %s, want %s (%s)", tt.from, got.Format("2006-01-02 15:04"), tt.want, tt.why)
+		}
+	}
+}
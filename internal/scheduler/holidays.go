@@ -0,0 +1,98 @@
+package scheduler
+
+import "time"
+
+// IsTradingDay reports whether the NYSE is open on the given date (in the date's location).
+func IsTradingDay(t time.Time) bool {
+	switch t.Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	return !IsMarketHoliday(t)
+}
+
+// IsMarketHoliday reports whether the date is a full-day NYSE holiday.
+// Early closes are treated as trading days.
+func IsMarketHoliday(t time.Time) bool {
+	year, month, day := t.Date()
+	for _, h := range marketHolidays(year) {
+		if h.Month() == month && h.Day() == day {
+			return true
+		}
+	}
+	return false
+}
+
+// marketHolidays returns the observed NYSE holidays for a year.
+func marketHolidays(year int) []time.Time {
+	holidays := []time.Time{
+		nthWeekday(year, time.January, time.Monday, 3),    // Martin Luther King Jr. Day
+		nthWeekday(year, time.February, time.Monday, 3),   // Washington's Birthday
+		easter(year).AddDate(0, 0, -2),                    // Good Friday
+		lastWeekday(year, time.May, time.Monday),          // Memorial Day
+		observed(date(year, time.July, 4)),                // Independence Day
+		nthWeekday(year, time.September, time.Monday, 1),  // Labor Day
+		nthWeekday(year, time.November, time.Thursday, 4), // Thanksgiving
+		observed(date(year, time.December, 25)),           // Christmas
+	}
+
+	// New Year's Day falling on a Saturday is not observed on the Friday before,
+	// since that would close the market on the last trading day of the year
+	if newYear := date(year, time.January, 1); newYear.Weekday() != time.Saturday {
+		holidays = append(holidays, observed(newYear))
+	}
+
+	if year >= 2022 {
+		holidays = append(holidays, observed(date(year, time.June, 19))) // Juneteenth
+	}
+
+	return holidays
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// observed moves a Saturday holiday to Friday and a Sunday holiday to Monday.
+func observed(t time.Time) time.Time {
+	switch t.Weekday() {
+	case time.Saturday:
+		return t.AddDate(0, 0, -1)
+	case time.Sunday:
+		return t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// nthWeekday returns the nth (1-based) given weekday of a month.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	t := date(year, month, 1)
+	offset := (int(weekday) - int(t.Weekday()) + 7) % 7
+	return t.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last given weekday of a month.
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	t := date(year, month+1, 1).AddDate(0, 0, -1)
+	offset := (int(t.Weekday()) - int(weekday) + 7) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+// easter returns Easter Sunday using the anonymous Gregorian algorithm.
+func easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), day)
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsTradingDay(t *testing.T) {
+	tests := []struct {
+		date string
+		want bool
+		why  string
+	}{
+		{"2021-01-01", false, "New Year's Day on a Friday"},
+		{"2021-12-31", true, "New Year's Day 2022 is a Saturday and not observed the Friday before"},
+		{"2022-01-03", true, "Saturday New Year's Day isn't moved to Monday either"},
+		{"2023-01-02", false, "New Year's Day on a Sunday, observed Monday"},
+		{"2024-01-15", false, "Martin Luther King Jr. Day"},
+		{"2024-02-19", false, "Washington's Birthday"},
+		{"2023-04-07", false, "Good Friday"},
+		{"2024-03-29", false, "Good Friday"},
+		{"2024-04-01", true, "Easter Monday is a trading day"},
+		{"2024-05-27", false, "Memorial Day"},
+		{"2021-06-18", true, "Juneteenth isn't a market holiday before 2022"},
+		{"2022-06-20", false, "Juneteenth 2022 on a Sunday, observed Monday"},
+		{"2023-06-19", false, "Juneteenth"},
+		{"2021-07-05", false, "Independence Day on a Sunday, observed Monday"},
+		{"2026-07-03", false, "Independence Day on a Saturday, observed Friday"},
+		{"2024-09-02", false, "Labor Day"},
+		{"2023-11-23", false, "Thanksgiving"},
+		{"2023-11-24", true, "Day after Thanksgiving closes early but trades"},
+		{"2022-12-26", false, "Christmas on a Sunday, observed Monday"},
+		{"2024-12-25", false, "Christmas"},
+		{"2024-03-02", false, "Saturday"},
+		{"2024-03-03", false, "Sunday"},
+		{"2024-03-04", true, "Ordinary Monday"},
+	}
+
+	for _, tt := range tests {
+		d, err := time.Parse("2006-01-02", tt.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := IsTradingDay(d); got != tt.want {
+			t.Errorf("IsTradingDay(%s) = %v, want %v (%s)", tt.date, got, tt.want, tt.why)
+		}
+	}
+}
+
+func TestEaster(t *testing.T) {
+	want := map[int]string{
+		2019: "2019-04-21",
+		2021: "2021-04-04",
+		2024: "2024-03-31",
+		2025: "2025-04-20",
+		2038: "2038-04-25", // Latest possible date
+	}
+	for year, date := range want {
+		if got := easter(year).Format("2006-01-02"); got != date {
+			t.Errorf("easter(%d) = %s, want %s", year, got, date)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// Package scheduler runs the nightly incremental ingestion on a cron schedule
+// stored in the settings table.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+	_ "time/tzdata" // Schedules are usually in America/New_York
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mauv0809/crispy-broccoli/internal/db"
+	"github.com/mauv0809/crispy-broccoli/internal/jobs"
+	"github.com/mauv0809/crispy-broccoli/internal/pipeline"
+)
+
+// Settings keys and their defaults (also seeded by migration 010).
+const (
+	SettingSchedule = "ingest_schedule"
+	SettingTimezone = "ingest_timezone"
+	SettingEnabled  = "ingest_schedule_enabled"
+
+	DefaultSchedule = "0 19 * * 1-5" // 7pm, after Sharadar's post-close update
+	DefaultTimezone = "America/New_York"
+)
+
+// maxSleep bounds how long the scheduler waits before re-reading its settings,
+// so schedule changes take effect without a restart.
+const maxSleep = 5 * time.Minute
+
+// A scheduled run that finds the ingestion lock held, usually by a manual run,
+// retries every lockRetryInterval for up to maxLockWait rather than skipping the day.
+const (
+	lockRetryInterval = time.Minute
+	maxLockWait       = 6 * time.Hour
+	clockSkew         = time.Minute // Between instances, when checking for their scheduled runs
+)
+
+// endpoint is recorded as the Endpoint of scheduled runs.
+const endpoint = "scheduler"
+
+// Step is one stage of the nightly run.
+type Step struct {
+	Kind string
+	Run  jobs.Func
+}
+
+// Status describes the schedule for IngestStatus.
+type Status struct {
+	Enabled   bool       `json:"enabled"`
+	Schedule  string     `json:"schedule"`
+	Timezone  string     `json:"timezone"`
+	NextRun   *time.Time `json:"next_run,omitempty"`
+	Running   bool       `json:"running"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	Error     string     `json:"error,omitempty"` // Invalid schedule or timezone setting
+}
+
+// Scheduler runs the ingestion steps in dependency order on a cron schedule,
+// skipping weekends and US market holidays. A run holds the runner's ingestion
+// lock throughout, so it never overlaps another run or a manually started job.
+type Scheduler struct {
+	repo   *db.Repository
+	runner *jobs.Runner
+	params pipeline.Params
+	steps  []Step
+
+	mu     sync.Mutex
+	status Status
+}
+
+// New creates a scheduler for the standard nightly run: tickers, then
+// fundamentals, then daily metrics, then SEP prices, then benchmarks, all
+// incremental. Fundamentals are fetched for dimensions, normally those the
+// registered strategies screen (analysis.Registry.Dimensions); the pipeline's
+// default is used when empty.
+func New(repo *db.Repository, p *pipeline.Pipeline, runner *jobs.Runner, dimensions []string) *Scheduler {
+	params := pipeline.Params{Dimensions: dimensions}
+	steps := []Step{
+		{"tickers", func(ctx context.Context, progress *jobs.Progress) (int, error) {
+			return p.Tickers(ctx, nil, progress)
+		}},
+		{"fundamentals", func(ctx context.Context, progress *jobs.Progress) (int, error) {
+			return p.Fundamentals(ctx, params, progress)
+		}},
+		{"daily", func(ctx context.Context, progress *jobs.Progress) (int, error) {
+			return p.Daily(ctx, params, progress)
+		}},
+		{"prices", func(ctx context.Context, progress *jobs.Progress) (int, error) {
+			return p.Prices(ctx, params, progress)
+		}},
+		{"benchmarks", func(ctx context.Context, progress *jobs.Progress) (int, error) {
+			return p.Benchmarks(ctx, params, progress)
+		}},
+	}
+
+	return &Scheduler{
+		repo:   repo,
+		runner: runner,
+		params: params,
+		steps:  steps,
+	}
+}
+
+// Start runs the scheduler loop until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	log.Println("Ingestion scheduler started")
+
+	for {
+		next, err := s.refresh(ctx, time.Now())
+		if err != nil {
+			log.Printf("Scheduler: %v", err)
+		}
+
+		wait := maxSleep
+		if !next.IsZero() && time.Until(next) < maxSleep {
+			wait = time.Until(next)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Println("Ingestion scheduler stopped")
+			return
+		case <-timer.C:
+		}
+
+		if !next.IsZero() && !time.Now().Before(next) {
+			if err := s.runScheduled(ctx, next); err != nil {
+				log.Printf("Scheduled ingestion failed: %v", err)
+			}
+		}
+	}
+}
+
+// runScheduled runs the ingestion due at due, waiting for the ingestion lock
+// while another run holds it. Every instance schedules the same runs, so once
+// another instance has started the one due, this one leaves it to that.
+func (s *Scheduler) runScheduled(ctx context.Context, due time.Time) error {
+	for {
+		err := s.RunNow(ctx)
+		if !errors.Is(err, jobs.ErrAlreadyRunning) {
+			return err
+		}
+
+		ran, checkErr := s.runner.RanSince(ctx, endpoint, due.Add(-clockSkew))
+		if checkErr != nil {
+			return checkErr
+		}
+		if ran {
+			log.Println("Scheduled ingestion started by another instance, skipping")
+			return nil
+		}
+		if time.Since(due) >= maxLockWait {
+			return fmt.Errorf("still waiting %v after the scheduled time: %w", maxLockWait, err)
+		}
+
+		log.Printf("Ingestion is running, retrying the scheduled run in %v", lockRetryInterval)
+		timer := time.NewTimer(lockRetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// RunNow runs every step in order, stopping at the first failure. Returns
+// jobs.ErrAlreadyRunning if a run or job is in progress in this or another instance.
+func (s *Scheduler) RunNow(ctx context.Context) error {
+	session, err := s.runner.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer session.Unlock()
+
+	start := time.Now()
+	s.update(func(st *Status) { st.Running = true })
+
+	log.Println("Starting scheduled ingestion...")
+
+	var runErr error
+	for _, step := range s.steps {
+		if _, err := session.Run(ctx, jobs.Spec{Kind: step.Kind, Endpoint: endpoint, Params: s.params}, step.Run); err != nil {
+			runErr = fmt.Errorf("%s: %w", step.Kind, err)
+			break
+		}
+	}
+
+	s.update(func(st *Status) {
+		st.Running = false
+		st.LastRun = &start
+		st.LastError = ""
+		if runErr != nil {
+			st.LastError = runErr.Error()
+		}
+	})
+
+	if runErr == nil {
+		log.Printf("Scheduled ingestion complete in %v", time.Since(start))
+	}
+	return runErr
+}
+
+// Status returns the current schedule, next run time and last run outcome.
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// refresh re-reads the schedule settings and computes the next run after now.
+// Returns the zero time when the schedule is disabled or invalid.
+func (s *Scheduler) refresh(ctx context.Context, now time.Time) (time.Time, error) {
+	expr, err := s.setting(ctx, SettingSchedule, DefaultSchedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	tz, err := s.setting(ctx, SettingTimezone, DefaultTimezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	enabledStr, err := s.setting(ctx, SettingEnabled, "true")
+	if err != nil {
+		return time.Time{}, err
+	}
+	enabled, _ := strconv.ParseBool(enabledStr)
+
+	var next time.Time
+	var configErr error
+
+	sched, err := ParseSchedule(expr)
+	if err != nil {
+		configErr = err
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		configErr = fmt.Errorf("invalid timezone %q: %w", tz, err)
+	}
+	if configErr == nil && enabled {
+		next = NextTradingRun(sched, now.In(loc))
+	}
+
+	s.update(func(st *Status) {
+		st.Enabled = enabled
+		st.Schedule = expr
+		st.Timezone = tz
+		st.NextRun = nil
+		if !next.IsZero() {
+			st.NextRun = &next
+		}
+		st.Error = ""
+		if configErr != nil {
+			st.Error = configErr.Error()
+		}
+	})
+
+	return next, configErr
+}
+
+// NextTradingRun returns the next time after t that matches the schedule and
+// falls on a trading day, judged in t's location.
+func NextTradingRun(sched *Schedule, t time.Time) time.Time {
+	// Each iteration moves at least a day, so a year of candidates is plenty
+	for i := 0; i < 366; i++ {
+		t = sched.Next(t)
+		if t.IsZero() || IsTradingDay(t) {
+			return t
+		}
+		// Skip to the end of this non-trading day
+		t = time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+func (s *Scheduler) setting(ctx context.Context, key, fallback string) (string, error) {
+	value, err := s.repo.GetSetting(ctx, key)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && value == "") {
+		return fallback, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s setting: %w", key, err)
+	}
+	return value, nil
+}
+
+func (s *Scheduler) update(fn func(*Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.status)
+}
@@ -8,7 +8,7 @@ package views
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
-func Index() templ.Component {
+func Index(strategies []string) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -41,7 +41,49 @@ func Index() templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"space-y-8\"><section class=\"card bg-base-200\"><div class=\"card-body\"><h2 class=\"card-title text-primary\">Portfolio Summary</h2><p class=\"text-base-content/70\">No holdings yet. Run an analysis to get started.</p><div id=\"portfolio-table\" class=\"mt-4\"><!-- Portfolio data will be loaded here --></div></div></section><section class=\"card bg-base-200\"><div class=\"card-body\"><h2 class=\"card-title text-primary\">Run Analysis</h2><div class=\"flex gap-4 items-end flex-wrap\"><div class=\"form-control\"><label class=\"label\"><span class=\"label-text\">Strategy</span></label> <select class=\"select select-bordered\"><option>Magic Formula</option> <option>Dividend Yield</option></select></div><button class=\"btn btn-primary\" hx-post=\"/analyze\" hx-target=\"#results-area\" hx-indicator=\"#loading\">Run Analysis</button> <span id=\"loading\" class=\"htmx-indicator loading loading-spinner loading-sm\"></span></div></div></section><section class=\"card bg-base-200\"><div class=\"card-body\"><h2 class=\"card-title text-primary\">Results</h2><div id=\"results-area\" class=\"text-base-content/70\"><p>Run an analysis to see recommendations.</p></div></div></section></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"space-y-8\"><section class=\"card bg-base-200\"><div class=\"card-body\"><div class=\"flex items-center justify-between\"><h2 class=\"card-title text-primary\">Portfolio Summary</h2><a href=\"/portfolio\" class=\"btn btn-sm btn-outline\">Manage</a></div><div id=\"portfolio-table\" class=\"mt-4\" hx-get=\"/portfolio/table\" hx-trigger=\"load\"><span class=\"loading loading-spinner loading-sm\"></span></div></div></section><section class=\"card bg-base-200\"><div class=\"card-body\"><h2 class=\"card-title text-primary\">Run Analysis</h2><div class=\"flex gap-4 items-end flex-wrap\"><div class=\"form-control\"><label class=\"label\"><span class=\"label-text\">Strategy</span></label> <select id=\"strategy\" name=\"strategy\" class=\"select select-bordered\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			for _, name := range strategies {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<option value=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var3 string
+				templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(name)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/views/index.templ`, Line: 28, Col: 29}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var4 string
+				templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(name)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/views/index.templ`, Line: 28, Col: 38}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "</option> ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			if len(strategies) == 0 {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<option disabled selected>No strategies available</option>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "</select></div><button class=\"btn btn-primary\" hx-post=\"/analyze\" hx-include=\"#strategy\" hx-target=\"#results-area\" hx-indicator=\"#loading\">Run Analysis</button> <span id=\"loading\" class=\"htmx-indicator loading loading-spinner loading-sm\"></span></div></div></section><section class=\"card bg-base-200\"><div class=\"card-body\"><div class=\"flex items-center justify-between\"><h2 class=\"card-title text-primary\">Rebalancing</h2><button class=\"btn btn-sm btn-outline\" hx-get=\"/rebalance\" hx-target=\"#rebalance-area\" hx-indicator=\"#rebalance-loading\">Calculate</button></div><span id=\"rebalance-loading\" class=\"htmx-indicator loading loading-spinner loading-sm\"></span><div id=\"rebalance-area\" class=\"text-base-content/70\"><p>Compare current weights to target weights.</p></div></div></section><section class=\"card bg-base-200\"><div class=\"card-body\"><h2 class=\"card-title text-primary\">Results</h2><div id=\"results-area\" class=\"text-base-content/70\"><p>Run an analysis to see recommendations.</p></div></div></section></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}